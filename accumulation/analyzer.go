@@ -26,6 +26,7 @@ import (
 	"go.uber.org/nilaway/annotation"
 	"go.uber.org/nilaway/assertion"
 	"go.uber.org/nilaway/assertion/function/assertiontree"
+	"go.uber.org/nilaway/assertion/function/functioncontracts"
 	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/diagnostic"
 	"go.uber.org/nilaway/inference"
@@ -45,8 +46,18 @@ var Analyzer = &analysis.Analyzer{
 	Doc:        _doc,
 	Run:        run,
 	FactTypes:  []analysis.Fact{new(inference.InferredMap)},
-	Requires:   []*analysis.Analyzer{config.Analyzer, assertion.Analyzer, annotation.Analyzer},
-	ResultType: reflect.TypeOf(([]analysis.Diagnostic)(nil)),
+	Requires:   []*analysis.Analyzer{config.Analyzer, assertion.Analyzer, annotation.Analyzer, annotation.LintAnalyzer, functioncontracts.CheckAnalyzer},
+	ResultType: reflect.TypeOf((*Result)(nil)),
+}
+
+// Result is the result of the accumulation analyzer.
+type Result struct {
+	// Diagnostics are the potential diagnostics for the upper-level analyzers to report.
+	Diagnostics []diagnostic.Diagnostic
+	// InferredMap is the inferred map of the package, including the information observed from
+	// upstream packages. It is nil if the package is not analyzed, and must be treated as
+	// read-only.
+	InferredMap *inference.InferredMap
 }
 
 // run is the primary driver function for NilAway's analysis.
@@ -82,48 +93,67 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 			// Deferred functions are executed after a result is generated, so here we modify the
 			// return value `result` in-place.
 			// Diagnostics with invalid positions (<= 0) will be silently suppressed, so here we use 1.
-			d := analysis.Diagnostic{Pos: 1, Message: fmt.Sprintf("INTERNAL PANIC: %s\n%s", r, string(debug.Stack()))}
-			if diagnostics, ok := result.([]analysis.Diagnostic); ok {
-				result = append(diagnostics, d)
-			} else {
-				result = []analysis.Diagnostic{d}
+			d := diagnostic.Diagnostic{
+				Diagnostic: analysis.Diagnostic{Pos: 1, Message: fmt.Sprintf("INTERNAL PANIC: %s\n%s", r, string(debug.Stack()))},
+			}
+			res, ok := result.(*Result)
+			if !ok || res == nil {
+				res = &Result{}
 			}
+			res.Diagnostics = append(res.Diagnostics, d)
+			result = res
 		}
 	}()
 
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
 	if !conf.IsPkgInScope(pass.Pkg) {
-		// Must return a typed nil since the driver is using reflection to retrieve the result.
-		return ([]analysis.Diagnostic)(nil), nil
+		return &Result{}, nil
 	}
 
 	assertionsResult := pass.ResultOf[assertion.Analyzer].(*analysishelper.Result[[]annotation.FullTrigger])
 	annotationsResult := pass.ResultOf[annotation.Analyzer].(*analysishelper.Result[*annotation.ObservedMap])
-	if err := errors.Join(annotationsResult.Err, assertionsResult.Err); err != nil {
+	lintResult := pass.ResultOf[annotation.LintAnalyzer].(*analysishelper.Result[[]analysis.Diagnostic])
+	contractsResult := pass.ResultOf[functioncontracts.CheckAnalyzer].(*analysishelper.Result[[]analysis.Diagnostic])
+	if err := errors.Join(annotationsResult.Err, assertionsResult.Err, lintResult.Err, contractsResult.Err); err != nil {
 		// For now, if there are any errors in the sub-analyzers, we directly emit diagnostics on the
 		// errors. However, in the future we could implement error recovery and make use of the partial
 		// information to continue the analysis.
 		// Diagnostics with invalid positions (<= 0) will be silently suppressed, so here we use 1.
-		return []analysis.Diagnostic{{Pos: 1, Message: fmt.Sprintf("INTERNAL ERROR(s):\n%s", err)}}, nil
+		return &Result{Diagnostics: []diagnostic.Diagnostic{{
+			Diagnostic: analysis.Diagnostic{Pos: 1, Message: fmt.Sprintf("INTERNAL ERROR(s):\n%s", err)},
+		}}}, nil
 	}
 
 	diagnosticEngine := diagnostic.NewEngine(pass)
+	// Report the malformed or dangling annotations (if enabled) and the violated handwritten
+	// contracts along with the nil panics, subject to the same category filtering and suppression.
+	diagnosticEngine.AddDiagnostics(config.CategoryAnnotation, lintResult.Res)
+	diagnosticEngine.AddDiagnostics(config.CategoryContract, contractsResult.Res)
 
 	// Create an inference engine and observe (load) information from upstream dependencies (i.e.,
 	// mappings between annotation sites and their inferred values).
 	inferenceEngine := inference.NewEngine(pass, diagnosticEngine)
+	if conf.DumpInferenceGraph != "" {
+		inferenceEngine.RecordGraph()
+	}
 	inferenceEngine.ObserveUpstream()
 
 	// Determine inference type based on comments in package doc string.
 	mode := inference.DetermineMode(pass)
 
+	// Load the annotations declared in the external stub files and the standard library model.
+	stubs, err := annotation.LoadStubs(conf.StubFiles, conf.StdlibModel)
+	if err != nil {
+		return nil, fmt.Errorf("load stub files: %w", err)
+	}
+
 	// First observe all annotations from annotationsResult (observes only syntactic annotations
-	// for FullInfer mode, otherwise all annotations for NoInfer)
-	inferenceEngine.ObserveAnnotations(annotationsResult.Res, mode)
+	// for FullInfer mode, otherwise all annotations for NoInfer) and the stub files.
+	inferenceEngine.ObserveAnnotations(annotationsResult.Res, stubs, mode)
 
 	var (
 		inferredMap *inference.InferredMap
-		diagnostics []analysis.Diagnostic
+		diagnostics []diagnostic.Diagnostic
 	)
 	switch mode {
 	case inference.FullInfer:
@@ -146,6 +176,12 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 		panic("Invalid mode for running NilAway")
 	}
 
+	if conf.DumpInferenceGraph != "" {
+		if err := inferenceEngine.Graph().Dump(conf.DumpInferenceGraph); err != nil {
+			return nil, fmt.Errorf("dump inference graph: %w", err)
+		}
+	}
+
 	// Export the _incremental_ information from this inferred map for analysis of downstream
 	// packages via the Fact mechanism (which [uses gob encoding under the hood]). The custom
 	// GobEncode / GobDecode methods of InferredAnnotationMap ensure that only incremental
@@ -157,7 +193,7 @@ func run(pass *analysis.Pass) (result interface{}, _ error) {
 	// [gob encoding]: https://pkg.go.dev/encoding/gob#hdr-Basics
 	inferredMap.Export(pass)
 
-	return diagnostics, nil
+	return &Result{Diagnostics: diagnostics, InferredMap: inferredMap}, nil
 }
 
 type conflictHandler interface {
@@ -883,7 +883,8 @@ func (a *ArgPassDeep) Copy() ConsumingAnnotationTrigger {
 
 // Prestring returns this ArgPassDeep as a Prestring
 func (a *ArgPassDeep) Prestring() Prestring {
-	switch key := a.Ann.(type) {
+	// The nested elements of the parameter are reported as the parameter itself.
+	switch key := baseKey(a.Ann).(type) {
 	case *ParamAnnotationKey:
 		return ArgPassPrestring{
 			ParamName:     key.MinimalString(),
@@ -1201,7 +1202,7 @@ func (u *UseAsReturnDeep) Copy() ConsumingAnnotationTrigger {
 
 // Prestring returns this UseAsReturn as a Prestring
 func (u *UseAsReturnDeep) Prestring() Prestring {
-	key := u.Ann.(*RetAnnotationKey)
+	key := baseKey(u.Ann).(*RetAnnotationKey)
 	return UseAsReturnDeepPrestring{
 		key.FuncDecl.Name(),
 		key.RetNum,
@@ -1823,6 +1824,51 @@ func (c ChanSendPrestring) String() string {
 	return sb.String()
 }
 
+// ElemAssign is when a value flows to a point where it is assigned into the nested elements of a
+// site beyond its elements, e.g., `x[i][j] = v` for a parameter `x` of type `[][]*int`.
+type ElemAssign struct {
+	*TriggerIfDeepNonNil
+}
+
+// equals returns true if the passed ConsumingAnnotationTrigger is equal to this one
+func (e *ElemAssign) equals(other ConsumingAnnotationTrigger) bool {
+	if other, ok := other.(*ElemAssign); ok {
+		return e.TriggerIfDeepNonNil.equals(other.TriggerIfDeepNonNil)
+	}
+	return false
+}
+
+// Copy returns a deep copy of this ConsumingAnnotationTrigger
+func (e *ElemAssign) Copy() ConsumingAnnotationTrigger {
+	copyConsumer := *e
+	copyConsumer.TriggerIfDeepNonNil = e.TriggerIfDeepNonNil.Copy().(*TriggerIfDeepNonNil)
+	return &copyConsumer
+}
+
+// Prestring returns this ElemAssign as a Prestring
+func (e *ElemAssign) Prestring() Prestring {
+	key := e.Ann.(*ElemAnnotationKey)
+	return ElemAssignPrestring{
+		Site:          key.describe(),
+		Depth:         key.Depth + 1,
+		AssignmentStr: e.assignmentFlow.String(),
+	}
+}
+
+// ElemAssignPrestring is a Prestring storing the needed information to compactly encode a ElemAssign
+type ElemAssignPrestring struct {
+	Site          string
+	Depth         int
+	AssignmentStr string
+}
+
+func (e ElemAssignPrestring) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("assigned deeply into the elements at depth %d of %s", e.Depth, e.Site))
+	sb.WriteString(e.AssignmentStr)
+	return sb.String()
+}
+
 // FldEscape is when a nilable value flows through a field of a struct that escapes.
 // The consumer is added for the fields at sites of escape.
 // There are 2 cases, that we currently consider as escaping:
@@ -0,0 +1,124 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/util"
+)
+
+// _defaultDirectiveRegex matches the `// nilaway:default(<nilability>)` directives, where the
+// space after the slashes is optional.
+var _defaultDirectiveRegex = regexp.MustCompile(`^//\s*nilaway:default\((.*)\)\s*$`)
+
+// defaultDirective is the default nilability of the unannotated sites declared in a package or a
+// file, declared by a `// nilaway:default(nilable)` or `// nilaway:default(nonnil)` directive.
+type defaultDirective struct {
+	// set indicates whether there is such a directive.
+	set     bool
+	nilable bool
+}
+
+// parseDefaultDirectives returns the directives in the comment group in the order they appear,
+// along with the comments that are directives but with unknown nilabilities.
+func parseDefaultDirectives(group *ast.CommentGroup) (directives []defaultDirective, unknown []*ast.Comment) {
+	if group == nil {
+		return nil, nil
+	}
+	for _, comment := range group.List {
+		m := _defaultDirectiveRegex.FindStringSubmatch(comment.Text)
+		if m == nil {
+			continue
+		}
+		switch strings.TrimSpace(m[1]) {
+		case nilableKeyword:
+			directives = append(directives, defaultDirective{set: true, nilable: true})
+		case nonNilKeyword:
+			directives = append(directives, defaultDirective{set: true, nilable: false})
+		default:
+			unknown = append(unknown, comment)
+		}
+	}
+	return directives, unknown
+}
+
+// fileDefaultDirectives returns the default directive in effect for each file. A directive in the
+// package doc (i.e., the doc comment of the package clause) of any file applies to all files of the
+// package, while a directive in other comments before the package clause (e.g., next to the build
+// constraints) only applies to the file and takes precedence. The first directive wins if there
+// are multiple ones in the same scope.
+func fileDefaultDirectives(files []*ast.File) map[*ast.File]defaultDirective {
+	var pkgDirective defaultDirective
+	fileDirectives := make(map[*ast.File]defaultDirective, len(files))
+	for _, file := range files {
+		if directives, _ := parseDefaultDirectives(file.Doc); len(directives) > 0 && !pkgDirective.set {
+			pkgDirective = directives[0]
+		}
+		for _, group := range file.Comments {
+			if group == file.Doc || group.Pos() > file.Package {
+				continue
+			}
+			if directives, _ := parseDefaultDirectives(group); len(directives) > 0 && !fileDirectives[file].set {
+				fileDirectives[file] = directives[0]
+			}
+		}
+	}
+
+	for _, file := range files {
+		if !fileDirectives[file].set {
+			fileDirectives[file] = pkgDirective
+		}
+	}
+	return fileDirectives
+}
+
+// siteDefaults are the default nilabilities of the unannotated sites declared in a file, which are
+// either annotated on the type parameters of the generic declarations, configured by the users for
+// certain types (see config.Config.DefaultNilability), or declared by the default directive of the
+// file.
+type siteDefaults struct {
+	conf      *config.Config
+	directive defaultDirective
+	// typeParams maps the annotated type parameters (e.g., `T` with `// nilable(T)`) to their
+	// nilability, it is shared by all files of the package since type parameters are unique.
+	typeParams map[*types.TypeParam]bool
+}
+
+// nilability returns the default nilability of a site of the given type (or of the elements of a
+// deep type), and false if there is none. The annotated type parameters take precedence over the
+// configured types, which in turn take precedence over the directive, while the directive does not
+// override the types that are nilable by default (e.g., slices and errors, see
+// TypeIsDefaultNilable), hence isDefaultNilable indicates if the type is such a type.
+func (d siteDefaults) nilability(t types.Type, isDefaultNilable bool) (nilable bool, ok bool) {
+	if tp, isTypeParam := t.(*types.TypeParam); isTypeParam {
+		nilable, ok = d.typeParams[tp]
+		return nilable, ok
+	}
+	if util.TypeBarsNilness(t) {
+		return false, false
+	}
+	if nilable, ok := d.conf.DefaultNilability(t); ok {
+		return nilable, true
+	}
+	if d.directive.set && !isDefaultNilable {
+		return d.directive.nilable, true
+	}
+	return false, false
+}
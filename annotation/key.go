@@ -461,8 +461,10 @@ type LocalVarAnnotationKey struct {
 }
 
 // Lookup looks this key up in the passed map, returning a Val
-// TODO: Add support for local variables with no inference (Currently, only works with inference)
-func (lk *LocalVarAnnotationKey) Lookup(_ Map) (Val, bool) {
+func (lk *LocalVarAnnotationKey) Lookup(annMap Map) (Val, bool) {
+	if localVal, ok := annMap.CheckLocalVarAnn(lk.VarDecl); ok {
+		return localVal, true
+	}
 	return nonAnnotatedDefault, false
 }
 
@@ -487,11 +489,90 @@ func (lk *LocalVarAnnotationKey) String() string {
 	return fmt.Sprintf("Local Variable %s", lk.VarDecl.Name())
 }
 
+// ElemAnnotationKey allows the Lookup of the annotations of the nested elements of a site beyond
+// its elements, e.g., the elements of the slices in a parameter of type `[][]*int`. It stands for
+// the element at Depth (>= 1) of the Base site, and is only used for the deep nilability (i.e.,
+// the nilability at Depth+1 of the Base site) in the deep triggers, since its shallow nilability
+// is already the deep nilability of a shallower key.
+type ElemAnnotationKey struct {
+	Base  Key
+	Depth int
+}
+
+// ElemKeyOf returns the key whose deep nilability is one level deeper than the deep nilability of
+// the passed key, i.e., the key of the elements of the passed key's elements.
+func ElemKeyOf(key Key) *ElemAnnotationKey {
+	if ek, ok := key.(*ElemAnnotationKey); ok {
+		return &ElemAnnotationKey{Base: ek.Base, Depth: ek.Depth + 1}
+	}
+	return &ElemAnnotationKey{Base: key, Depth: 1}
+}
+
+// baseKey returns the base key of the passed key if it is an element key, and the key itself
+// otherwise.
+func baseKey(key Key) Key {
+	if ek, ok := key.(*ElemAnnotationKey); ok {
+		return ek.Base
+	}
+	return key
+}
+
+// describe returns a human-readable description of the site of the base key (e.g., "parameter
+// `x`" or "result 0 of `f()`") for the messages of the triggers on the element keys.
+func (ek *ElemAnnotationKey) describe() string {
+	switch key := ek.Base.(type) {
+	case *ParamAnnotationKey:
+		return fmt.Sprintf("parameter `%s`", key.ParamNameString())
+	case *CallSiteParamAnnotationKey:
+		return fmt.Sprintf("parameter `%s`", key.ParamNameString())
+	case *RecvAnnotationKey:
+		return fmt.Sprintf("receiver of `%s()`", key.FuncDecl.Name())
+	case *FieldAnnotationKey:
+		return fmt.Sprintf("field `%s`", key.FieldDecl.Name())
+	case *GlobalVarAnnotationKey:
+		return fmt.Sprintf("global variable `%s`", key.VarDecl.Name())
+	case *LocalVarAnnotationKey:
+		return fmt.Sprintf("local variable `%s`", key.VarDecl.Name())
+	case *RetAnnotationKey:
+		return fmt.Sprintf("result %d of `%s()`", key.RetNum, key.FuncDecl.Name())
+	case *CallSiteRetAnnotationKey:
+		return fmt.Sprintf("result %d of `%s()`", key.RetNum, key.FuncDecl.Name())
+	default:
+		return fmt.Sprintf("`%s`", key.Object().Name())
+	}
+}
+
+// Lookup looks this key up in the passed map, returning a Val
+func (ek *ElemAnnotationKey) Lookup(annMap Map) (Val, bool) {
+	if elemVal, ok := annMap.CheckElemAnn(ek); ok {
+		return elemVal, true
+	}
+	return nonAnnotatedDefault, false
+}
+
+// Object returns the types.Object that this annotation can best be interpreted as annotating
+func (ek *ElemAnnotationKey) Object() types.Object {
+	return ek.Base.Object()
+}
+
+func (ek *ElemAnnotationKey) equals(other Key) bool {
+	if other, ok := other.(*ElemAnnotationKey); ok {
+		return ek.Depth == other.Depth && ek.Base.equals(other.Base)
+	}
+	return false
+}
+
+func (ek *ElemAnnotationKey) copy() Key {
+	return &ElemAnnotationKey{Base: ek.Base.copy(), Depth: ek.Depth}
+}
+
+func (ek *ElemAnnotationKey) String() string {
+	return fmt.Sprintf("%s Elem %d", ek.Base.String(), ek.Depth)
+}
+
 // RetFieldAnnotationKey allows the Lookup of the Annotation on a specific field within a function's return of struct
 // (or pointer to struct) type, in the Annotation Map. This key is only effective when the struct initialization checking
 // is enabled.
-//
-// TODO: Add support for field of function return with no inference (Currently, only works with inference)
 type RetFieldAnnotationKey struct {
 	// FuncDecl is the function type of function containing return
 	FuncDecl *types.Func
@@ -501,8 +582,13 @@ type RetFieldAnnotationKey struct {
 	FieldDecl *types.Var
 }
 
-// Lookup looks this key up in the passed map, returning a Val.
-func (rf *RetFieldAnnotationKey) Lookup(_ Map) (Val, bool) {
+// Lookup looks this key up in the passed map, returning a Val. Since the fields of function returns
+// cannot be annotated, they are looked up as the fields themselves (i.e., a returned struct is
+// assumed to respect the annotations of its fields) when there is no inference.
+func (rf *RetFieldAnnotationKey) Lookup(annMap Map) (Val, bool) {
+	if fieldVal, ok := annMap.CheckFieldAnn(rf.FieldDecl); ok {
+		return fieldVal, true
+	}
 	return nonAnnotatedDefault, false
 }
 
@@ -555,10 +641,12 @@ type EscapeFieldAnnotationKey struct {
 	FieldDecl *types.Var
 }
 
-// Lookup looks this key up in the passed map, returning a Val
-// Currently, the annotation key is used only with inference
-// TODO: This should be updated on supporting no-infer with struct initialization
-func (ek *EscapeFieldAnnotationKey) Lookup(_ Map) (Val, bool) {
+// Lookup looks this key up in the passed map, returning a Val. Similar to RetFieldAnnotationKey,
+// the escaping fields are looked up as the fields themselves when there is no inference.
+func (ek *EscapeFieldAnnotationKey) Lookup(annMap Map) (Val, bool) {
+	if fieldVal, ok := annMap.CheckFieldAnn(ek.FieldDecl); ok {
+		return fieldVal, true
+	}
 	return nonAnnotatedDefault, false
 }
 
@@ -0,0 +1,416 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/util"
+	"go.uber.org/nilaway/util/analysishelper"
+	"golang.org/x/tools/go/analysis"
+)
+
+const _lintDoc = "Validate the nilability annotations in this package, reporting the ones that are" +
+	" malformed or have no effect (e.g., annotating unknown names)"
+
+// LintAnalyzer is the analyzer that validates the nilability annotations read by Analyzer. Since
+// malformed or dangling annotations (e.g., `// nilable(reuslt 0)`) are silently ignored by the
+// reader, it reports them such that the users are aware. It only runs if the annotation lint is
+// enabled in the config.
+var LintAnalyzer = &analysis.Analyzer{
+	Name:       "nilaway_annotation_lint_analyzer",
+	Doc:        _lintDoc,
+	Run:        analysishelper.WrapRun(lint),
+	ResultType: reflect.TypeOf((*analysishelper.Result[[]analysis.Diagnostic])(nil)),
+	Requires:   []*analysis.Analyzer{config.Analyzer},
+}
+
+// _annotationLikeRegex matches the start of anything that looks like a nilability annotation.
+var _annotationLikeRegex = regexp.MustCompile(fmt.Sprintf(`\b%s\(`, annotationKeyword))
+
+func lint(pass *analysis.Pass) ([]analysis.Diagnostic, error) {
+	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+	if !conf.LintAnnotations || !conf.IsPkgInScope(pass.Pkg) {
+		return nil, nil
+	}
+
+	l := &linter{pass: pass}
+	for _, file := range pass.Files {
+		if conf.IsFileInScope(file) {
+			l.lintFile(file)
+		}
+	}
+	return l.diagnostics, nil
+}
+
+// lintTarget describes the sites that can be annotated by the annotations in a comment group,
+// mirroring how the names are looked up when reading the annotations (see newObservedMap).
+type lintTarget struct {
+	// desc describes what the comment group is attached to, e.g., "function `foo`".
+	desc string
+	// sites maps the names that can be annotated to the types of the sites. A nil type means the
+	// type of the site is not checked.
+	sites map[string]types.Type
+	// params and results are the names of the parameters and results of the function (empty for
+	// the unnamed ones), which are used to check the `param <N>` and `result <N>` names.
+	params, results []string
+	// locals are the names of the local variables among the sites, whose shallow nilability
+	// cannot be annotated since they are tracked flow-sensitively (only their elements can be).
+	locals map[string]bool
+}
+
+// addLocals adds the local variables declared by the annotated statement to the sites of the target.
+func (t *lintTarget) addLocals(vars []*types.Var) {
+	if t.locals == nil {
+		t.locals = make(map[string]bool, len(vars))
+	}
+	for _, v := range vars {
+		t.sites[v.Name()] = v.Type()
+		t.locals[v.Name()] = true
+	}
+}
+
+// linter validates the nilability annotations in the files.
+type linter struct {
+	pass        *analysis.Pass
+	diagnostics []analysis.Diagnostic
+}
+
+func (l *linter) report(pos token.Pos, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, analysis.Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// lintFile validates all annotations in the file: the ones in the comment groups that are read
+// by the annotation reader are checked against the sites they can annotate, and the ones in
+// other comment groups are reported as dangling.
+func (l *linter) lintFile(file *ast.File) {
+	// Malformed annotations are ignored no matter where they are.
+	for _, group := range file.Comments {
+		l.checkMalformed(group)
+	}
+	l.checkDefaultDirectives(file)
+
+	checked := make(map[*ast.CommentGroup]bool)
+	check := func(group *ast.CommentGroup, target *lintTarget) {
+		if group != nil {
+			checked[group] = true
+			l.checkGroup(group, target)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			fn, ok := l.pass.TypesInfo.ObjectOf(decl.Name).(*types.Func)
+			if !ok {
+				continue
+			}
+			target := l.funcTarget(fn, "function `"+fn.Name()+"`", true /* byName */)
+			if recv := fn.Type().(*types.Signature).Recv(); recv != nil && recv.Name() != "" && recv.Name() != "_" {
+				target.sites[recv.Name()] = recv.Type()
+			}
+			check(decl.Doc, target)
+
+		case *ast.GenDecl:
+			// Same as the reader, the doc of the declaration is read for single-spec declarations,
+			// and the docs of the specs are read otherwise.
+			doc := func(specDoc *ast.CommentGroup) *ast.CommentGroup {
+				if len(decl.Specs) == 1 {
+					return decl.Doc
+				}
+				return specDoc
+			}
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					if decl.Tok != token.VAR {
+						continue
+					}
+					target := &lintTarget{desc: "variable declaration", sites: make(map[string]types.Type)}
+					for _, name := range spec.Names {
+						if obj := l.pass.TypesInfo.ObjectOf(name); obj != nil {
+							target.sites[name.Name] = obj.Type()
+						}
+					}
+					check(doc(spec.Doc), target)
+				case *ast.TypeSpec:
+					target := &lintTarget{desc: "type `" + spec.Name.Name + "`", sites: make(map[string]types.Type)}
+					if named, ok := l.pass.TypesInfo.ObjectOf(spec.Name).Type().(*types.Named); ok && spec.TypeParams != nil {
+						target.addTypeParams(named.TypeParams())
+					}
+					typeExpr := spec.Type
+					for paren, ok := typeExpr.(*ast.ParenExpr); ok; paren, ok = typeExpr.(*ast.ParenExpr) {
+						typeExpr = paren.X
+					}
+					switch typeExpr := typeExpr.(type) {
+					case *ast.StructType:
+						for _, field := range typeExpr.Fields.List {
+							for _, name := range field.Names {
+								if obj := l.pass.TypesInfo.ObjectOf(name); obj != nil {
+									target.sites[name.Name] = obj.Type()
+								}
+							}
+							l.checkStructTag(field, target)
+						}
+					case *ast.StarExpr, *ast.MapType, *ast.ArrayType:
+						// The deep nilability of the declared type itself.
+						target.sites[spec.Name.Name] = nil
+					case *ast.InterfaceType:
+						for _, method := range typeExpr.Methods.List {
+							if len(method.Names) != 1 {
+								continue
+							}
+							fn, ok := l.pass.TypesInfo.ObjectOf(method.Names[0]).(*types.Func)
+							if !ok {
+								continue
+							}
+							check(method.Doc, l.funcTarget(fn, "method `"+fn.Name()+"`", true /* byName */))
+						}
+					}
+					check(doc(spec.Doc), target)
+				}
+			}
+		}
+	}
+
+	// The remaining comment groups are either annotating the local variable declarations or the
+	// call sites on the same lines (or both), or dangling (i.e., having no effect).
+	locals := localVarDecls(l.pass, file)
+	callees := l.calleesByLine(file)
+	for _, group := range file.Comments {
+		if checked[group] || len(parseAnnotations(group)) == 0 {
+			continue
+		}
+		line := l.pass.Fset.Position(group.Pos()).Line
+		isCallSite := len(group.List) == 1 && len(callees[line]) > 0
+		switch {
+		case isCallSite:
+			for _, fn := range callees[line] {
+				target := l.funcTarget(fn, "call to `"+fn.Name()+"`", false /* byName */)
+				target.addLocals(locals[group])
+				l.checkGroup(group, target)
+			}
+		case len(locals[group]) > 0:
+			target := &lintTarget{desc: "local variable declaration", sites: make(map[string]types.Type)}
+			target.addLocals(locals[group])
+			l.checkGroup(group, target)
+		default:
+			l.report(group.Pos(), "nilability annotation is not attached to any declaration or call and has no effect")
+		}
+	}
+}
+
+// calleesByLine returns the functions (including the upstream ones) called on each line of the
+// file, whose call sites can be annotated by the comments on the same lines.
+func (l *linter) calleesByLine(file *ast.File) map[int][]*types.Func {
+	callees := make(map[int][]*types.Func)
+	ast.Inspect(file, func(node ast.Node) bool {
+		expr, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		ident := util.FuncIdentFromCallExpr(expr)
+		if ident == nil {
+			return true
+		}
+		if fn, ok := l.pass.TypesInfo.ObjectOf(ident).(*types.Func); ok {
+			line := l.pass.Fset.Position(expr.Pos()).Line
+			callees[line] = append(callees[line], fn)
+		}
+		return true
+	})
+	return callees
+}
+
+// funcTarget returns the lint target for the parameters and results of the function. If byName is
+// true, the named parameters and results are annotated by their names (as in the function docs),
+// otherwise all of them are annotated by `param <N>` and `result <N>` (as in the call sites).
+func (l *linter) funcTarget(fn *types.Func, desc string, byName bool) *lintTarget {
+	sig := fn.Type().(*types.Signature)
+	target := &lintTarget{desc: desc, sites: make(map[string]types.Type)}
+	add := func(tuple *types.Tuple, keyOf func(int) string, isParams bool) []string {
+		names := make([]string, tuple.Len())
+		for i := 0; i < tuple.Len(); i++ {
+			v := tuple.At(i)
+			typ := v.Type()
+			if isParams && sig.Variadic() && i == tuple.Len()-1 {
+				// Same as the reader, the variadic parameter `...T` is treated as having type `T`.
+				if s, ok := typ.(*types.Slice); ok {
+					typ = s.Elem()
+				}
+			}
+			key := keyOf(i)
+			if byName && v.Name() != "" {
+				names[i], key = v.Name(), v.Name()
+			}
+			target.sites[key] = typ
+		}
+		return names
+	}
+	target.params = add(sig.Params(), paramStr, true /* isParams */)
+	target.results = add(sig.Results(), resultStr, false /* isParams */)
+	if byName {
+		target.addTypeParams(sig.RecvTypeParams())
+		target.addTypeParams(sig.TypeParams())
+	}
+	return target
+}
+
+// addTypeParams adds the type parameters as the sites of the target, which can be annotated to
+// set the nilability of all sites of their types (e.g., `// nilable(T)`).
+func (t *lintTarget) addTypeParams(params *types.TypeParamList) {
+	for i := 0; i < params.Len(); i++ {
+		t.sites[params.At(i).Obj().Name()] = params.At(i)
+	}
+}
+
+// checkMalformed reports the annotation-like texts in the comment group that cannot be parsed.
+func (l *linter) checkMalformed(group *ast.CommentGroup) {
+	for _, comment := range group.List {
+		parsed := make(map[int]bool)
+		for _, match := range seqRegex.FindAllStringIndex(comment.Text, -1) {
+			parsed[match[0]] = true
+		}
+		for _, match := range _annotationLikeRegex.FindAllStringIndex(comment.Text, -1) {
+			if !parsed[match[0]] {
+				l.report(comment.Slash+token.Pos(match[0]),
+					"malformed nilability annotation, expect comma-separated names, `param <N>` or `result <N>` "+
+						"(optionally deep as `*<name>`, `<name>[]` or `<-<name>`, repeated for deeper levels) in `%s(...)`",
+					comment.Text[match[0]:match[1]-1])
+			}
+		}
+	}
+}
+
+// checkDefaultDirectives reports the default directives with unknown nilabilities, and the ones
+// after the package clause that have no effect.
+func (l *linter) checkDefaultDirectives(file *ast.File) {
+	for _, group := range file.Comments {
+		directives, unknown := parseDefaultDirectives(group)
+		for _, comment := range unknown {
+			l.report(comment.Slash, "unknown default nilability in `%s`, expect `nilaway:default(nilable)` or `nilaway:default(nonnil)`", comment.Text)
+		}
+		if len(directives) > 0 && group.Pos() > file.Package {
+			l.report(group.Pos(), "default nilability directive is not before the package clause and has no effect")
+		}
+	}
+}
+
+// checkGroup validates the annotations in the comment group against the sites of the target.
+func (l *linter) checkGroup(group *ast.CommentGroup, target *lintTarget) {
+	l.checkAnnotations(parseAnnotations(group), target)
+}
+
+// checkAnnotations validates the annotations against the sites of the target.
+func (l *linter) checkAnnotations(annotations []parsedAnnotation, target *lintTarget) {
+	// seen maps the annotated names (and the depths of the annotations) to their nilability.
+	type seenKey struct {
+		name  string
+		depth int
+	}
+	seen := make(map[seenKey]bool)
+
+	for _, a := range annotations {
+		typ, ok := target.sites[a.name]
+		if !ok {
+			l.reportUnknown(a, target)
+			continue
+		}
+
+		key := seenKey{name: a.name, depth: a.depth}
+		if nilable, ok := seen[key]; ok {
+			if nilable != a.nilable {
+				kind := "nilability"
+				switch {
+				case a.depth == 1:
+					kind = "deep nilability"
+				case a.depth > 1:
+					kind = fmt.Sprintf("depth %d nilability", a.depth)
+				}
+				l.report(a.pos, "conflicting %s annotations on `%s`, it is annotated as both nilable and nonnil", kind, a.name)
+			}
+			continue
+		}
+		seen[key] = a.nilable
+
+		if typ == nil {
+			continue
+		}
+		if a.depth == 0 {
+			switch {
+			case util.TypeBarsNilness(typ.Underlying()):
+				l.report(a.pos, "nilability annotation on `%s` has no effect since its type `%s` can never be nil", a.name, typ)
+			case target.locals[a.name]:
+				l.report(a.pos, "nilability annotation on local variable `%s` has no effect since only the nilability of its elements can be annotated", a.name)
+			}
+			continue
+		}
+		// Same as the default deep nilability, the multi-dimensional arrays are deeply nilable if
+		// their innermost elements are (see ElemTypes).
+		elems := ElemTypes(typ)
+		if a.depth >= len(elems) || util.TypeBarsNilness(elems[a.depth].Underlying()) {
+			if a.depth == 1 {
+				l.report(a.pos, "deep nilability annotation on `%s` has no effect since its type `%s` has no nilable elements", a.name, typ)
+			} else {
+				l.report(a.pos, "depth %d nilability annotation on `%s` has no effect since its type `%s` has no nilable elements at that depth", a.depth, a.name, typ)
+			}
+		}
+	}
+}
+
+// checkStructTag validates the nilability annotations in the struct tag of the field.
+func (l *linter) checkStructTag(field *ast.Field, target *lintTarget) {
+	for i, name := range field.Names {
+		annotations, unknown := parseStructTag(field.Tag, name.Name)
+		for _, v := range unknown {
+			if i > 0 {
+				// The unknown values are only reported once for the fields sharing the tag.
+				break
+			}
+			l.report(field.Tag.Pos(), "unknown value %q in `%s` struct tag, expect comma-separated \"nilable\", \"nonnil\", \"deepnilable\" or \"deepnonnil\"", v, structTagKey)
+		}
+		l.checkAnnotations(annotations, target)
+	}
+}
+
+// reportUnknown reports the annotation on a name that does not match any site of the target.
+func (l *linter) reportUnknown(a parsedAnnotation, target *lintTarget) {
+	var kind string
+	var index int
+	if _, err := fmt.Sscanf(a.name, "param %d", &index); err == nil {
+		kind = "parameter"
+	} else if _, err := fmt.Sscanf(a.name, "result %d", &index); err == nil {
+		kind = "result"
+	}
+
+	names := target.params
+	if kind == "result" {
+		names = target.results
+	}
+	switch {
+	case kind == "":
+		l.report(a.pos, "unknown name `%s` in nilability annotation on %s", a.name, target.desc)
+	case index >= len(names):
+		l.report(a.pos, "`%s` in nilability annotation is out of range, %s has %d %s(s)", a.name, target.desc, len(names), kind)
+	default:
+		l.report(a.pos, "`%s` in nilability annotation has no effect since the %s is named, use `%s` instead", a.name, kind, names[index])
+	}
+}
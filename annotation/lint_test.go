@@ -0,0 +1,63 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"go/ast"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, parseAnnotations(nil))
+
+	group := &ast.CommentGroup{List: []*ast.Comment{
+		{Slash: 10, Text: "// nilable(x,  *y, result 0) nonnil(z[])"},
+		{Slash: 100, Text: "// nonnil(<-c) and nilable(reuslt 0) is malformed"},
+		{Slash: 200, Text: "// nilable(x[][], **p, <-*c)"},
+	}}
+	require.Equal(t, []parsedAnnotation{
+		{name: "x", pos: 10 + 11, nilable: true},
+		{name: "y", pos: 10 + 15, nilable: true, depth: 1},
+		{name: "result 0", pos: 10 + 19, nilable: true},
+		{name: "z", pos: 10 + 36, depth: 1},
+		{name: "c", pos: 100 + 10, depth: 1},
+		{name: "x", pos: 200 + 11, nilable: true, depth: 2},
+		{name: "p", pos: 200 + 18, nilable: true, depth: 2},
+		{name: "c", pos: 200 + 23, nilable: true, depth: 2},
+	}, parseAnnotations(group))
+}
+
+func TestParseStructTag(t *testing.T) {
+	t.Parallel()
+
+	annotations, unknown := parseStructTag(nil, "f")
+	require.Empty(t, annotations)
+	require.Empty(t, unknown)
+
+	annotations, unknown = parseStructTag(&ast.BasicLit{ValuePos: 10, Value: "`json:\"f\"`"}, "f")
+	require.Empty(t, annotations)
+	require.Empty(t, unknown)
+
+	annotations, unknown = parseStructTag(&ast.BasicLit{ValuePos: 10, Value: "`json:\"f\" nilaway:\"nilable, deepnonnil,nilabel\"`"}, "f")
+	require.Equal(t, []parsedAnnotation{
+		{name: "f", pos: 10, nilable: true},
+		{name: "f", pos: 10, depth: 1},
+	}, annotations)
+	require.Equal(t, []string{"nilabel"}, unknown)
+}
@@ -19,8 +19,11 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/util"
@@ -35,6 +38,8 @@ type Map interface {
 	CheckFuncRecvAnn(*types.Func) (Val, bool)
 	CheckDeepTypeAnn(*types.TypeName) (Val, bool)
 	CheckGlobalVarAnn(*types.Var) (Val, bool)
+	CheckLocalVarAnn(*types.Var) (Val, bool)
+	CheckElemAnn(*ElemAnnotationKey) (Val, bool)
 	CheckFuncCallSiteParamAnn(*CallSiteParamAnnotationKey) (Val, bool)
 	CheckFuncCallSiteRetAnn(*CallSiteRetAnnotationKey) (Val, bool)
 }
@@ -45,8 +50,19 @@ type Val struct {
 	IsDeepNilable    bool
 	IsNilableSet     bool
 	IsDeepNilableSet bool
+	// DeeperLevels is the number of levels of nested elements deeper than the deep one (e.g., 1
+	// for a `[][]*int` site, where the elements of its elements are at depth 2), whose nilabilities
+	// are stored in the bits of DeeperNilable (and DeeperNilableSet) in order, i.e., the i-th bit is
+	// for depth i+2.
+	DeeperLevels     int
+	DeeperNilable    uint64
+	DeeperNilableSet uint64
 }
 
+// MaxDepth is the maximum depth of the nested elements whose nilabilities are tracked, where the
+// shallow nilability is at depth 0 and the deep nilability is at depth 1.
+const MaxDepth = 65
+
 // EmptyVal indicates an annotation value that is fully nonnil but not "set"
 var EmptyVal = Val{
 	IsNilable:        false,
@@ -55,6 +71,66 @@ var EmptyVal = Val{
 	IsDeepNilableSet: false,
 }
 
+// NilableAt returns the nilability at the given depth (0 for the shallow nilability and 1 for the
+// deep nilability), and whether it is set.
+func (a Val) NilableAt(depth int) (nilable bool, set bool) {
+	switch {
+	case depth == 0:
+		return a.IsNilable, a.IsNilableSet
+	case depth == 1:
+		return a.IsDeepNilable, a.IsDeepNilableSet
+	case depth-2 < a.DeeperLevels:
+		bit := uint64(1) << (depth - 2)
+		return a.DeeperNilable&bit != 0, a.DeeperNilableSet&bit != 0
+	}
+	return false, false
+}
+
+// Elem returns the value of the element at the given depth, i.e., whose shallow and deep
+// nilabilities are the ones at depth and depth+1 of this value.
+func (a Val) Elem(depth int) Val {
+	if depth == 0 {
+		return a
+	}
+	elem := EmptyVal
+	elem.IsNilable, elem.IsNilableSet = a.NilableAt(depth)
+	elem.IsDeepNilable, elem.IsDeepNilableSet = a.NilableAt(depth + 1)
+	if depth < a.DeeperLevels {
+		elem.DeeperLevels = a.DeeperLevels - depth
+		elem.DeeperNilable = a.DeeperNilable >> depth
+		elem.DeeperNilableSet = a.DeeperNilableSet >> depth
+	}
+	return elem
+}
+
+// makeNilableAt inspects a Val to see if its nilability at the given depth has already been set.
+// If it has, then makeNilableAt is a noop, otherwise, it returns a copy of the passed Val with the
+// nilability at the depth set to the given one (and the levels extended to the depth if needed).
+// The parameter isFinalVal is the same as the one of makeNilable.
+func (a Val) makeNilableAt(depth int, nilable bool, isFinalVal bool) Val {
+	if _, set := a.NilableAt(depth); set {
+		return a
+	}
+	switch {
+	case depth == 0:
+		a.IsNilable, a.IsNilableSet = nilable, isFinalVal
+	case depth == 1:
+		a.IsDeepNilable, a.IsDeepNilableSet = nilable, isFinalVal
+	case depth < MaxDepth:
+		bit := uint64(1) << (depth - 2)
+		a.DeeperLevels = max(a.DeeperLevels, depth-1)
+		a.DeeperNilable &^= bit
+		a.DeeperNilableSet &^= bit
+		if nilable {
+			a.DeeperNilable |= bit
+		}
+		if isFinalVal {
+			a.DeeperNilableSet |= bit
+		}
+	}
+	return a
+}
+
 // makeNilable inspects a Val to see if its nilability has already been set.
 // If it has, then makeNilable is a noop, otherwise, it returns a copy of the passed
 // Val with the nilability set to true.
@@ -62,15 +138,7 @@ var EmptyVal = Val{
 // can update the `Val` further - uses cases are commented on to discuss why
 // this is or is not desirable in given cases.
 func (a Val) makeNilable(isFinalVal bool) Val {
-	if a.IsNilableSet {
-		return a
-	}
-	return Val{
-		IsNilable:        true,
-		IsDeepNilable:    a.IsDeepNilable,
-		IsNilableSet:     isFinalVal,
-		IsDeepNilableSet: a.IsDeepNilableSet,
-	}
+	return a.makeNilableAt(0, true, isFinalVal)
 }
 
 // makeDeepNilable inspects a Val to see if its deep nilability has already been set.
@@ -80,15 +148,7 @@ func (a Val) makeNilable(isFinalVal bool) Val {
 // can update the `Val` further - uses cases are commented on to discuss why
 // this is or is not desirable in given cases.
 func (a Val) makeDeepNilable(isFinalVal bool) Val {
-	if a.IsDeepNilableSet {
-		return a
-	}
-	return Val{
-		IsNilable:        a.IsNilable,
-		IsDeepNilable:    true,
-		IsNilableSet:     a.IsNilableSet,
-		IsDeepNilableSet: isFinalVal,
-	}
+	return a.makeNilableAt(1, true, isFinalVal)
 }
 
 // makeNonNil inspects a Val to see if its nilability has already been set.
@@ -98,15 +158,7 @@ func (a Val) makeDeepNilable(isFinalVal bool) Val {
 // can update the `Val` further - uses cases are commented on to discuss why
 // this is or is not desirable in given cases.
 func (a Val) makeNonNil(isFinalVal bool) Val {
-	if a.IsNilableSet {
-		return a
-	}
-	return Val{
-		IsNilable:        false,
-		IsDeepNilable:    a.IsDeepNilable,
-		IsNilableSet:     isFinalVal,
-		IsDeepNilableSet: a.IsDeepNilableSet,
-	}
+	return a.makeNilableAt(0, false, isFinalVal)
 }
 
 // makeDeepNonNil inspects a Val to see if its deep nilability has already been set.
@@ -116,15 +168,7 @@ func (a Val) makeNonNil(isFinalVal bool) Val {
 // can update the `Val` further - uses cases are commented on to discuss why
 // this is or is not desirable in given cases.
 func (a Val) makeDeepNonNil(isFinalVal bool) Val {
-	if a.IsDeepNilableSet {
-		return a
-	}
-	return Val{
-		IsNilable:        a.IsNilable,
-		IsDeepNilable:    false,
-		IsNilableSet:     a.IsNilableSet,
-		IsDeepNilableSet: isFinalVal,
-	}
+	return a.makeNilableAt(1, false, isFinalVal)
 }
 
 // A ObservedMap represents a completed set of annotations read from a file or set of files,
@@ -156,6 +200,9 @@ type ObservedMap struct {
 	// this maps declarations of global variables to their annotations
 	globalVarsAnnMap map[*types.Var]Val
 
+	// this maps declarations of the annotated local variables to their annotations
+	localVarAnnMap map[*types.Var]Val
+
 	// funcCallSiteParamAnnMap maps a function call site to a slice with the annotations of its
 	// duplicated params at the call site.
 	funcCallSiteParamAnnMap map[CallSite][]ArgLocAndVal
@@ -189,6 +236,12 @@ func (m *ObservedMap) Range(op func(key Key, isDeep bool, val bool), setSitesOnl
 		if !setSitesOnly || val.IsDeepNilableSet {
 			op(key, true /* isDeep */, val.IsDeepNilable)
 		}
+		// The nilabilities of the deeper levels are the deep nilabilities of the element keys.
+		for depth := 2; depth <= val.DeeperLevels+1; depth++ {
+			if nilable, set := val.NilableAt(depth); !setSitesOnly || set {
+				op(&ElemAnnotationKey{Base: key, Depth: depth - 1}, true /* isDeep */, nilable)
+			}
+		}
 	}
 
 	for fld, val := range m.fieldAnnMap {
@@ -219,6 +272,10 @@ func (m *ObservedMap) Range(op func(key Key, isDeep bool, val bool), setSitesOnl
 		callOpOnKeyVal(&GlobalVarAnnotationKey{VarDecl: gvar}, val)
 	}
 
+	for lvar, val := range m.localVarAnnMap {
+		callOpOnKeyVal(&LocalVarAnnotationKey{VarDecl: lvar}, val)
+	}
+
 	for callSite, vals := range m.funcCallSiteParamAnnMap {
 		for i, argLocAndVal := range vals {
 			// the location inside the callSite is the location of the call expression, we want
@@ -268,118 +325,180 @@ func resultStr(i int) string {
 	return fmt.Sprintf(resultTemplateStr, fmt.Sprintf("%d", i))
 }
 
-var deepIdentRegexStr = fmt.Sprintf("((\\*%s)|(%s\\[\\])|(<-%s)|%s)",
-	tokenRegexStr, tokenRegexStr, tokenRegexStr, tokenRegexStr)
+// deepIdentRegexStr matches a name optionally marked deep by any number of `*` or `<-` prefixes
+// and `[]` suffixes, e.g., `*x`, `x[][]` or `<-*c`, where the number of markers is the depth.
+var deepIdentRegexStr = fmt.Sprintf("((?:\\*|<-)*%s(?:\\[\\])*)", tokenRegexStr)
 var seqRegexStr = fmt.Sprintf("%s\\((\\s*%s\\s*(%s\\s*%s\\s*)*)\\)",
 	annotationKeyword, deepIdentRegexStr, sep, deepIdentRegexStr)
 var seqRegex = regexp.MustCompile(seqRegexStr)
 
 type nilabilitySet map[string]Val
 
-// from a CommentGroup return a nilabilitySet of which identifiers are known annotated nilable
-func nilabilityFromCommentGroup(group *ast.CommentGroup) nilabilitySet {
-	set := make(nilabilitySet)
-	// in each of the following utility functions, isFinalVal=true because literally read annotations
-	// are considered final
-	markNilable := func(s string) {
-		if v, ok := set[s]; ok {
-			set[s] = v.makeNilable(true)
-		} else {
-			set[s] = EmptyVal.makeNilable(true)
-		}
+// parsedAnnotation is a single name in a nilability annotation (e.g., `x` or `*y` in
+// `nilable(x, *y)`) read from a comment.
+type parsedAnnotation struct {
+	// name is the annotated name without the deep markers, e.g., "x" or "result 0".
+	name string
+	// pos is the position of the annotated name (including the deep markers) in the comment.
+	pos token.Pos
+	// nilable indicates whether the name is annotated as nilable (or nonnil otherwise).
+	nilable bool
+	// depth is the depth of the annotated nilability of the name, i.e., the number of the deep
+	// markers (`*<name>`, `<name>[]`, or `<-<name>`), where 0 is the shallow nilability and 1 is
+	// the deep nilability (e.g., 2 for `x[][]` or `**p`).
+	depth int
+}
+
+// parseAnnotations reads the nilability annotations from a CommentGroup, in the order they appear.
+func parseAnnotations(group *ast.CommentGroup) []parsedAnnotation {
+	if group == nil {
+		return nil
 	}
-	markDeepNilable := func(s string) {
-		if v, ok := set[s]; ok {
-			set[s] = v.makeDeepNilable(true)
-		} else {
-			set[s] = EmptyVal.makeDeepNilable(true)
+
+	var annotations []parsedAnnotation
+	for _, comment := range group.List {
+		for _, seqMatch := range seqRegex.FindAllStringSubmatchIndex(comment.Text, -1) {
+			nilable := comment.Text[seqMatch[2]:seqMatch[3]] == nilableKeyword
+
+			offset := seqMatch[4]
+			for _, match := range strings.Split(comment.Text[seqMatch[4]:seqMatch[5]], sep) {
+				matchOffset := offset + len(match) - len(strings.TrimLeftFunc(match, unicode.IsSpace))
+				offset += len(match) + len(sep)
+
+				a := parsedAnnotation{pos: comment.Slash + token.Pos(matchOffset), nilable: nilable}
+				a.name = strings.TrimSpace(match)
+				for {
+					if name, ok := strings.CutPrefix(a.name, "*"); ok {
+						a.name = name
+					} else if name, ok := strings.CutPrefix(a.name, "<-"); ok {
+						a.name = name
+					} else if name, ok := strings.CutSuffix(a.name, "[]"); ok {
+						a.name = name
+					} else {
+						break
+					}
+					a.depth++
+				}
+				annotations = append(annotations, a)
+			}
 		}
 	}
-	markNonNil := func(s string) {
-		if v, ok := set[s]; ok {
-			set[s] = v.makeNonNil(true)
-		} else {
-			set[s] = EmptyVal.makeNonNil(true)
+	return annotations
+}
+
+// from a CommentGroup return a nilabilitySet of which identifiers are known annotated nilable
+func nilabilityFromCommentGroup(group *ast.CommentGroup) nilabilitySet {
+	set := make(nilabilitySet)
+	set.mark(parseAnnotations(group)...)
+	return set
+}
+
+// mark marks the names in the set as annotated by the annotations.
+func (set nilabilitySet) mark(annotations ...parsedAnnotation) {
+	// in each of the following cases, isFinalVal=true because literally read annotations are
+	// considered final
+	for _, a := range annotations {
+		v, ok := set[a.name]
+		if !ok {
+			v = EmptyVal
 		}
+		set[a.name] = v.makeNilableAt(a.depth, a.nilable, true)
 	}
-	markDeepNonNil := func(s string) {
-		if v, ok := set[s]; ok {
-			set[s] = v.makeDeepNonNil(true)
-		} else {
-			set[s] = EmptyVal.makeDeepNonNil(true)
+}
+
+// markTypeParams records the shallow nilability annotated in the set for the given type parameters
+// (e.g., `// nilable(T)` on a generic declaration) in the map, which then applies to the sites of
+// the type parameters in the declaration (see siteDefaults).
+func (set nilabilitySet) markTypeParams(anns map[*types.TypeParam]bool, params *types.TypeParamList) {
+	for i := 0; i < params.Len(); i++ {
+		tp := params.At(i)
+		if v, ok := set[tp.Obj().Name()]; ok {
+			if nilable, ok := v.NilableAt(0); ok {
+				anns[tp] = nilable
+			}
 		}
 	}
+}
 
-	if group != nil {
-		for _, comment := range group.List {
-			for _, seqMatch := range seqRegex.FindAllStringSubmatch(comment.Text, -1) {
-
-				deepFunc, shallowFunc := markDeepNonNil, markNonNil
-				if seqMatch[1] == nilableKeyword {
-					deepFunc, shallowFunc = markDeepNilable, markNilable
-				}
-
-				for _, match := range strings.Split(seqMatch[2], sep) {
-					match = strings.TrimSpace(match)
-					n := len(match)
-
-					if n >= 2 && match[0] == '*' {
-						deepFunc(match[1:])
-						continue
-					}
-
-					if n >= 3 && match[n-2:] == "[]" {
-						deepFunc(match[:n-2])
-						continue
-					}
-
-					if n >= 3 && match[:2] == "<-" {
-						deepFunc(match[2:])
-						continue
-					}
+// structTagKey is the key in the struct tags of the fields for annotating their nilability (e.g.,
+// `nilaway:"nilable"`), which is useful for the generated structs whose doc comments cannot be
+// controlled.
+const structTagKey = "nilaway"
+
+// structTagValues maps the values accepted in the struct tags to the nilability (and its depth)
+// they annotate.
+var structTagValues = map[string]struct {
+	nilable bool
+	depth   int
+}{
+	"nilable":     {nilable: true},
+	"nonnil":      {nilable: false},
+	"deepnilable": {nilable: true, depth: 1},
+	"deepnonnil":  {nilable: false, depth: 1},
+}
 
-					shallowFunc(match)
-				}
-			}
+// parseStructTag parses the nilability annotations on the field of the given name from the
+// `nilaway` key in its struct tag, which is a comma-separated list of "nilable", "nonnil",
+// "deepnilable" and "deepnonnil" (e.g., `nilaway:"nonnil,deepnilable"`). The values that are not
+// recognized are returned separately.
+func parseStructTag(tag *ast.BasicLit, name string) (annotations []parsedAnnotation, unknown []string) {
+	if tag == nil {
+		return nil, nil
+	}
+	str, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return nil, nil
+	}
+	value, ok := reflect.StructTag(str).Lookup(structTagKey)
+	if !ok {
+		return nil, nil
+	}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		a, ok := structTagValues[v]
+		if !ok {
+			unknown = append(unknown, v)
+			continue
 		}
+		annotations = append(annotations, parsedAnnotation{name: name, pos: tag.Pos(), nilable: a.nilable, depth: a.depth})
 	}
-
-	return set
+	return annotations, unknown
 }
 
 // TypeIsDefaultNilable takes a type and returns true iff we assume default nilability for that
-// type - in contrast to the remaining cases, in which we assume default non-nil.
-func TypeIsDefaultNilable(t types.Type) bool {
+// type - in contrast to the remaining cases, in which we assume default non-nil. The default
+// nilabilities configured for the named types (see config.Config.DefaultNilability) take
+// precedence, conf can be nil if there is no configuration.
+func TypeIsDefaultNilable(t types.Type, conf *config.Config) bool {
 	if t == nil {
 		return false
 	}
 
+	if conf != nil {
+		if nilable, ok := conf.DefaultNilability(t); ok {
+			return nilable
+		}
+	}
+
 	// Builtin error type should be nilable by default.
 	if types.Identical(t, util.ErrorType) {
 		return true
 	}
 
-	// Slice, map, and chan should also be nilable by default (after unwrapping the named type).
-	switch t.Underlying().(type) {
+	// Slice, map, and chan should also be nilable by default (after unwrapping the named type),
+	// and so are the type parameters whose constraints only admit such types (e.g., `S ~[]E`).
+	switch util.CoreType(t).(type) {
 	case *types.Slice, *types.Map, *types.Chan:
 		return true
 	}
-
-	// Additionally, we allow custom default nilable types provided by the users.
-	if t, ok := t.(*types.Named); ok {
-		for _, defaultNilableNamedType := range config.DefaultNilableNamedTypes {
-			if t.String() == defaultNilableNamedType {
-				return true
-			}
-		}
-	}
 	return false
 }
 
 // TypeIsDeepDefaultNilable takes an `ast.Expr` that evaluates to a type, and returns true iff
 // we assume default deep nilability for that type - in contrast to the remaining cases, in which
-// we assume default deep non-nil.
-func TypeIsDeepDefaultNilable(t types.Type) bool {
+// we assume default deep non-nil. Similar to TypeIsDefaultNilable, the configured default
+// nilabilities of the element types take precedence, and conf can be nil.
+func TypeIsDeepDefaultNilable(t types.Type, conf *config.Config) bool {
 	switch t := t.(type) {
 	case *types.Array:
 		// the array case is handled different from others, since an array is not default nilable,
@@ -387,20 +506,29 @@ func TypeIsDeepDefaultNilable(t types.Type) bool {
 
 		// recurse if multi-dimensional array until containing type is reached
 		if e, ok := t.Elem().(*types.Array); ok {
-			return TypeIsDeepDefaultNilable(e)
+			return TypeIsDeepDefaultNilable(e, conf)
+		}
+		// assign deep nilability based on the element type, unless it is configured
+		if conf != nil {
+			if nilable, ok := conf.DefaultNilability(t.Elem()); ok {
+				return nilable
+			}
 		}
-		// assign deep nilability based on the element type
 		return !util.TypeBarsNilness(t.Elem())
 	case *types.Slice:
-		return TypeIsDefaultNilable(t.Elem())
+		return TypeIsDefaultNilable(t.Elem(), conf)
 	case *types.Map:
-		return TypeIsDefaultNilable(t.Elem())
+		return TypeIsDefaultNilable(t.Elem(), conf)
 	case *types.Pointer:
-		return TypeIsDefaultNilable(t.Elem())
+		return TypeIsDefaultNilable(t.Elem(), conf)
 	case *types.Chan:
-		return TypeIsDefaultNilable(t.Elem())
+		return TypeIsDefaultNilable(t.Elem(), conf)
 	case *types.Named:
-		return TypeIsDeepDefaultNilable(t.Underlying())
+		return TypeIsDeepDefaultNilable(t.Underlying(), conf)
+	case *types.TypeParam:
+		if core := util.CoreType(t); core != nil {
+			return TypeIsDeepDefaultNilable(core, conf)
+		}
 	}
 	return false
 }
@@ -409,21 +537,64 @@ func TypeIsDeepDefaultNilable(t types.Type) bool {
 // set. If it is, then that Annotation is returned. If not, then `nonNil` is returned.
 // the type of the Annotation site is also passed, and it can possibly serve to mark a site
 // as `nilable` when its Annotation doesn't indicate so.
-func (set nilabilitySet) checkNilability(name string, t types.Type) Val {
+func (set nilabilitySet) checkNilability(name string, t types.Type, defaults siteDefaults) Val {
 	val := EmptyVal
 	if v, ok := set[name]; ok {
 		val = v
 	}
-	// in each of the following cases, isFinalVal=false because defaults are not considered final
-	if TypeIsDefaultNilable(t) {
-		val = val.makeNilable(false)
+	if t == nil {
+		return val
 	}
-	if TypeIsDeepDefaultNilable(t) {
-		val = val.makeDeepNilable(false)
+
+	elems := ElemTypes(t)
+	for depth, typ := range elems {
+		// The default nilability of the deep elements is determined by the type of their
+		// containers, while the nested elements beyond them are nonnil by default.
+		isDefaultNilable := false
+		switch depth {
+		case 0:
+			isDefaultNilable = TypeIsDefaultNilable(typ, defaults.conf)
+		case 1:
+			isDefaultNilable = TypeIsDeepDefaultNilable(elems[0], defaults.conf)
+		}
+		// The default nilabilities configured by the users (or declared by the directives) are
+		// considered final such that they are honored by the inference as well, while the
+		// annotations still take precedence.
+		if nilable, ok := defaults.nilability(typ, isDefaultNilable); ok {
+			val = val.makeNilableAt(depth, nilable, true)
+		}
+		// isFinalVal=false because the defaults are not considered final
+		if isDefaultNilable || depth >= 2 {
+			val = val.makeNilableAt(depth, isDefaultNilable, false)
+		}
 	}
 	return val
 }
 
+// ElemTypes returns the types of the site of the given type (at depth 0) and its nested elements
+// at each depth (up to MaxDepth), e.g., `[][]*int`, `[]*int`, `*int` and `int` for a `[][]*int`
+// site. Same as the default deep nilability, the (multi-dimensional) arrays are flattened such
+// that their innermost elements are at the next depth. The nested elements of the elements of
+// named types are not included, since they are annotated by the named types instead.
+func ElemTypes(t types.Type) []types.Type {
+	elems := []types.Type{t}
+	for len(elems) < MaxDepth {
+		last := elems[len(elems)-1]
+		if _, ok := last.(*types.Named); ok && len(elems) > 1 {
+			break
+		}
+		elem, ok := util.TypeAsDeepType(last.Underlying())
+		if !ok {
+			break
+		}
+		for arr, isArr := elem.(*types.Array); isArr; arr, isArr = elem.(*types.Array) {
+			elem = arr.Elem()
+		}
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
 func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
 	// TODO - only store annotations for fields/vars/parameters of types that do not bar nilness
@@ -435,8 +606,10 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 	paramIndexMap := make(map[*types.Var]int)
 	deepTypeAnnMap := make(map[*types.TypeName]Val)
 	globalVarsAnnMap := make(map[*types.Var]Val)
+	localVarAnnMap := make(map[*types.Var]Val)
 
 	funcObjToFuncDecl := make(map[*types.Func]*ast.FuncDecl)
+	funcDefaults := make(map[*types.Func]siteDefaults)
 	funcCallSiteParamAnnMap := make(map[CallSite][]ArgLocAndVal)
 	funcCallSiteRetAnnMap := make(map[CallSite][]Val)
 
@@ -447,7 +620,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 	// for a function declaration, accumulate its parameters from an *ast.Fieldlist object
 	// listing them, look them up in the docstring, and return an equally long list of
 	// annotationVals
-	accFromFieldList := func(set nilabilitySet, fieldList *ast.FieldList, isParamList bool,
+	accFromFieldList := func(set nilabilitySet, defaults siteDefaults, fieldList *ast.FieldList, isParamList bool,
 		isCallSiteAnnotation bool) []Val {
 		if fieldList == nil {
 			// this is included for nil-safety
@@ -467,7 +640,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 					lookupKey = resultStr(len(annVals))
 				}
 
-				annVals = append(annVals, set.checkNilability(lookupKey, typeOf(field.Type)))
+				annVals = append(annVals, set.checkNilability(lookupKey, typeOf(field.Type), defaults))
 			} else {
 				for _, name := range field.Names {
 					declFld := pass.TypesInfo.ObjectOf(name).(*types.Var)
@@ -494,35 +667,82 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 					} else {
 						lookupKey = name.Name
 					}
-					annVals = append(annVals, set.checkNilability(lookupKey, fieldType))
+					annVals = append(annVals, set.checkNilability(lookupKey, fieldType, defaults))
 				}
 			}
 		}
 		return annVals
 	}
 
-	readRecvAnnotations := func(decl *ast.FuncDecl, set nilabilitySet) Val {
+	readRecvAnnotations := func(decl *ast.FuncDecl, set nilabilitySet, defaults siteDefaults) Val {
 		if decl.Recv != nil {
 			if len(decl.Recv.List) > 1 {
 				panic(fmt.Sprintf("Multiple receivers found for method %s", decl.Name))
 			}
-			return accFromFieldList(set, decl.Recv, false, false)[0]
+			return accFromFieldList(set, defaults, decl.Recv, false, false)[0]
 		}
 		return nonAnnotatedDefault
 	}
 
+	// The annotations on the type parameters of the generic types (e.g., `// nilable(T)` on `type
+	// Box[T any] struct{...}`) apply to their methods as well, hence they are read before the other
+	// declarations.
+	typeParamAnnMap := make(map[*types.TypeParam]bool)
+	for _, file := range files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				named, ok := pass.TypesInfo.ObjectOf(spec.Name).Type().(*types.Named)
+				if !ok || spec.TypeParams == nil {
+					continue
+				}
+				doc := spec.Doc
+				if len(decl.Specs) == 1 {
+					doc = decl.Doc
+				}
+				nilabilityFromCommentGroup(doc).markTypeParams(typeParamAnnMap, named.TypeParams())
+			}
+		}
+	}
+
+	directives := fileDefaultDirectives(files)
 	for _, file := range files {
 		if conf.IsFileInScope(file) {
+			defaults := siteDefaults{conf: conf, directive: directives[file], typeParams: typeParamAnnMap}
 			for _, decl := range file.Decls {
 				switch decl := decl.(type) {
 				case *ast.FuncDecl:
 					funcObj := pass.TypesInfo.ObjectOf(decl.Name).(*types.Func)
 					set := nilabilityFromCommentGroup(decl.Doc)
-					funcParamAnnMap[funcObj] = accFromFieldList(set, decl.Type.Params, true, false)
-					funcRetAnnMap[funcObj] = accFromFieldList(set, decl.Type.Results, false, false)
-					funcRecvAnnMap[funcObj] = readRecvAnnotations(decl, set)
-					// store the mapping from the function object to the ast node.
+					// The type parameters of the receiver (which are declared anew by every method
+					// of a generic type) follow the annotations on those of the generic type,
+					// while the doc of the method may still override them.
+					sig := funcObj.Type().(*types.Signature)
+					if recv := sig.RecvTypeParams(); recv.Len() > 0 {
+						if named, ok := util.UnwrapPtr(sig.Recv().Type()).(*types.Named); ok {
+							for i := 0; i < recv.Len(); i++ {
+								if nilable, ok := typeParamAnnMap[named.Origin().TypeParams().At(i)]; ok {
+									typeParamAnnMap[recv.At(i)] = nilable
+								}
+							}
+						}
+					}
+					set.markTypeParams(typeParamAnnMap, sig.RecvTypeParams())
+					set.markTypeParams(typeParamAnnMap, sig.TypeParams())
+					funcParamAnnMap[funcObj] = accFromFieldList(set, defaults, decl.Type.Params, true, false)
+					funcRetAnnMap[funcObj] = accFromFieldList(set, defaults, decl.Type.Results, false, false)
+					funcRecvAnnMap[funcObj] = readRecvAnnotations(decl, set, defaults)
+					// store the mapping from the function object to the ast node (and the defaults
+					// of its file for the annotations at its call sites).
 					funcObjToFuncDecl[funcObj] = decl
+					funcDefaults[funcObj] = defaults
 				case *ast.GenDecl:
 					// this is used for any declaration besides a function
 					// here, we specifically look for declarations of struct types
@@ -550,7 +770,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 								for _, name := range spec.Names {
 									varObj := pass.TypesInfo.ObjectOf(name).(*types.Var)
 									globalVarsAnnMap[varObj] =
-										docNilabilitySet.checkNilability(name.Name, typeOf(spec.Type))
+										docNilabilitySet.checkNilability(name.Name, typeOf(spec.Type), defaults)
 								}
 							}
 						case *ast.TypeSpec:
@@ -563,7 +783,7 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 							readDeepNilability := func() {
 								typeName := pass.TypesInfo.ObjectOf(spec.Name).(*types.TypeName)
 								deepTypeAnnMap[typeName] =
-									docNilabilitySet.checkNilability(spec.Name.Name, typeOf(spec.Type))
+									docNilabilitySet.checkNilability(spec.Name.Name, typeOf(spec.Type), defaults)
 							}
 							var handleTypeVal func(expr ast.Expr)
 							handleTypeVal = func(expr ast.Expr) {
@@ -571,8 +791,12 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 								case *ast.StructType:
 									for _, field := range typeVal.Fields.List {
 										for _, name := range field.Names {
+											// The struct tags are read in addition to the doc
+											// comment, where the latter takes precedence.
+											tagged, _ := parseStructTag(field.Tag, name.Name)
+											docNilabilitySet.mark(tagged...)
 											fieldAnnMap[pass.TypesInfo.ObjectOf(name).(*types.Var)] =
-												docNilabilitySet.checkNilability(name.Name, typeOf(field.Type))
+												docNilabilitySet.checkNilability(name.Name, typeOf(field.Type), defaults)
 										}
 									}
 								case *ast.InterfaceType:
@@ -583,8 +807,8 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 											// this is the common case - a simply declared method
 											set := nilabilityFromCommentGroup(method.Doc)
 											funcObj := pass.TypesInfo.ObjectOf(method.Names[0]).(*types.Func)
-											funcParamAnnMap[funcObj] = accFromFieldList(set, method.Type.(*ast.FuncType).Params, true, false)
-											funcRetAnnMap[funcObj] = accFromFieldList(set, method.Type.(*ast.FuncType).Results, false, false)
+											funcParamAnnMap[funcObj] = accFromFieldList(set, defaults, method.Type.(*ast.FuncType).Params, true, false)
+											funcRetAnnMap[funcObj] = accFromFieldList(set, defaults, method.Type.(*ast.FuncType).Results, false, false)
 										case 0:
 										// this is the case of inheritance - i.e. a method with another
 										// method named within it, in this case the identifiers will
@@ -607,7 +831,9 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 								case *ast.ChanType:
 									// TODO - treat channel types as deeply nilable at the typedef level
 								case *ast.IndexExpr, *ast.IndexListExpr:
-									// TODO - handle generics
+									// instantiated generic type - do nothing, since the fields and
+									// methods of the instances are looked up by their generic
+									// origins (see inference.primitivizer.site)
 								case *ast.ParenExpr:
 									handleTypeVal(typeVal.X)
 								default:
@@ -625,6 +851,33 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 		}
 	}
 
+	// Parse annotations on the declarations of local variables. Unlike other sites, only the
+	// annotated local variables are stored, such that the unannotated ones are still treated
+	// optimistically in no-infer mode. Moreover, only the deep annotations are honored since the
+	// local variables themselves are tracked flow-sensitively (the shallow ones are reported by
+	// the linter instead).
+	for _, file := range files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		defaults := siteDefaults{conf: conf, directive: directives[file], typeParams: typeParamAnnMap}
+		annotations := make(map[*types.Var][]parsedAnnotation)
+		for group, vars := range localVarDecls(pass, file) {
+			for _, a := range parseAnnotations(group) {
+				for _, v := range vars {
+					if a.name == v.Name() && a.depth > 0 {
+						annotations[v] = append(annotations[v], a)
+					}
+				}
+			}
+		}
+		for v, anns := range annotations {
+			set := make(nilabilitySet)
+			set.mark(anns...)
+			localVarAnnMap[v] = set.checkNilability(v.Name(), v.Type(), defaults)
+		}
+	}
+
 	// Parse inline annotations at call sites.
 	for _, file := range files {
 		if !conf.IsFileInScope(file) {
@@ -666,25 +919,37 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 				// not a function, keep searching for nested CallExpr nodes.
 				return true
 			}
+			// the methods of the instantiated generic types are declared by their generic origins.
+			funcObj = funcObj.Origin()
 
 			set := nilabilityFromCommentGroup(commentGroup)
 			if len(set) == 0 {
 				// empty set, no annotation, keep searching for nested CallExpr nodes.
 				return true
 			}
-			funcDecl, ok := funcObjToFuncDecl[funcObj]
-			if !ok {
-				panic(funcObj.FullName() + " not found but this should not happen since we " +
-					"have parsed annotations once for every function declaration and the " +
-					"mappings should have been set up.")
+			var paramVals, retVals []Val
+			if funcDecl, ok := funcObjToFuncDecl[funcObj]; ok {
+				defaults := funcDefaults[funcObj]
+				paramVals = accFromFieldList(set, defaults, funcDecl.Type.Params, true, true)
+				retVals = accFromFieldList(set, defaults, funcDecl.Type.Results, false, true)
+			} else {
+				// The function is declared elsewhere (e.g., an upstream function whose contracts
+				// are imported as facts), so the annotations are matched against its signature,
+				// where the directives of its declaring file do not apply.
+				paramVals, retVals = accFromSignature(set, siteDefaults{conf: conf},
+					funcObj.Type().(*types.Signature))
 			}
 			callSite := CallSite{Fun: funcObj, Location: util.PosToLocation(expr.Pos(), pass)}
-			for i, val := range accFromFieldList(set, funcDecl.Type.Params, true, true) {
+			for i, val := range paramVals {
+				if i >= len(expr.Args) {
+					// no argument is passed for the variadic parameter.
+					break
+				}
 				argLoc := util.PosToLocation(expr.Args[i].Pos(), pass)
 				funcCallSiteParamAnnMap[callSite] = append(funcCallSiteParamAnnMap[callSite],
 					ArgLocAndVal{Location: argLoc, Val: val})
 			}
-			funcCallSiteRetAnnMap[callSite] = accFromFieldList(set, funcDecl.Type.Results, false, true)
+			funcCallSiteRetAnnMap[callSite] = retVals
 			// keep searching for nested CallExpr nodes.
 			return true
 		})
@@ -696,11 +961,93 @@ func newObservedMap(pass *analysis.Pass, files []*ast.File) *ObservedMap {
 		funcRecvAnnMap:          funcRecvAnnMap,
 		deepTypeAnnMap:          deepTypeAnnMap,
 		globalVarsAnnMap:        globalVarsAnnMap,
+		localVarAnnMap:          localVarAnnMap,
 		funcCallSiteParamAnnMap: funcCallSiteParamAnnMap,
 		funcCallSiteRetAnnMap:   funcCallSiteRetAnnMap,
 	}
 }
 
+// accFromSignature returns the annotations of the parameters and results of a function at a call
+// site from the set, which are looked up by their positions (e.g., `param 0` and `result 0`) in
+// the signature of the function.
+func accFromSignature(set nilabilitySet, defaults siteDefaults, sig *types.Signature) (params []Val, results []Val) {
+	for i := 0; i < sig.Params().Len(); i++ {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == sig.Params().Len()-1 {
+			// we treat the variadic arguments as having type `T` not type `[]T`, see
+			// accFromFieldList in newObservedMap.
+			if slice, ok := t.(*types.Slice); ok {
+				t = slice.Elem()
+			}
+		}
+		params = append(params, set.checkNilability(paramStr(i), t, defaults))
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		results = append(results, set.checkNilability(resultStr(i), sig.Results().At(i).Type(), defaults))
+	}
+	return params, results
+}
+
 func getLineFromPos(pos token.Pos, pass *analysis.Pass) int {
 	return pass.Fset.Position(pos).Line
 }
+
+// localVarDecls returns the local variables declared by the statements (`var x T` and `x := ...`)
+// in the file, keyed by the comment groups that annotate them: the comment group directly above
+// the statement (at the same indentation), and the single comment on the first line of the
+// statement (which may annotate the call sites on that line as well).
+func localVarDecls(pass *analysis.Pass, file *ast.File) map[*ast.CommentGroup][]*types.Var {
+	// Index the comment groups by the lines they end on, and the single comments by their lines.
+	above := make(map[int]*ast.CommentGroup)
+	sameLine := make(map[int]*ast.CommentGroup)
+	for _, group := range file.Comments {
+		above[getLineFromPos(group.End(), pass)] = group
+		if len(group.List) == 1 {
+			sameLine[getLineFromPos(group.Pos(), pass)] = group
+		}
+	}
+
+	decls := make(map[*ast.CommentGroup][]*types.Var)
+	ast.Inspect(file, func(node ast.Node) bool {
+		var idents []*ast.Ident
+		switch stmt := node.(type) {
+		case *ast.DeclStmt:
+			if decl, ok := stmt.Decl.(*ast.GenDecl); ok && decl.Tok == token.VAR {
+				for _, spec := range decl.Specs {
+					idents = append(idents, spec.(*ast.ValueSpec).Names...)
+				}
+			}
+		case *ast.AssignStmt:
+			if stmt.Tok == token.DEFINE {
+				for _, lhs := range stmt.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						idents = append(idents, ident)
+					}
+				}
+			}
+		default:
+			return true
+		}
+
+		var vars []*types.Var
+		for _, ident := range idents {
+			// Only the newly declared variables (e.g., not the redeclared ones in `x, err := ...`)
+			// are defined by the statement.
+			if v, ok := pass.TypesInfo.Defs[ident].(*types.Var); ok && v.Name() != "_" {
+				vars = append(vars, v)
+			}
+		}
+		if len(vars) == 0 {
+			return true
+		}
+		position := pass.Fset.Position(node.Pos())
+		if group, ok := above[position.Line-1]; ok && pass.Fset.Position(group.Pos()).Column == position.Column {
+			decls[group] = append(decls[group], vars...)
+		}
+		if group, ok := sameLine[position.Line]; ok && group.Pos() > node.Pos() {
+			decls[group] = append(decls[group], vars...)
+		}
+		return true
+	})
+	return decls
+}
@@ -1241,6 +1241,36 @@ func (g GlobalVarReadDeepPrestring) String() string {
 	return fmt.Sprintf("deep read from global variable `%s`", g.VarName)
 }
 
+// ElemRead is when a value is determined to flow from a read of the nested elements of a site
+// beyond its elements, e.g., `x[i][j]` for a parameter `x` of type `[][]*int`.
+type ElemRead struct {
+	*TriggerIfDeepNilable
+}
+
+// equals returns true if the passed ProducingAnnotationTrigger is equal to this one
+func (e *ElemRead) equals(other ProducingAnnotationTrigger) bool {
+	if other, ok := other.(*ElemRead); ok {
+		return e.TriggerIfDeepNilable.equals(other.TriggerIfDeepNilable)
+	}
+	return false
+}
+
+// Prestring returns this ElemRead as a Prestring
+func (e *ElemRead) Prestring() Prestring {
+	key := e.Ann.(*ElemAnnotationKey)
+	return ElemReadPrestring{Site: key.describe(), Depth: key.Depth + 1}
+}
+
+// ElemReadPrestring is a Prestring storing the needed information to compactly encode a ElemRead
+type ElemReadPrestring struct {
+	Site  string
+	Depth int
+}
+
+func (e ElemReadPrestring) String() string {
+	return fmt.Sprintf("deep read from the elements at depth %d of %s", e.Depth, e.Site)
+}
+
 // GuardMissing is when a value is determined to flow from a site that requires a guard,
 // to a site that is not guarded by that guard.
 //
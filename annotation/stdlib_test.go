@@ -0,0 +1,151 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/packages"
+	"gopkg.in/yaml.v3"
+)
+
+// TestStdlibModel checks the bundled standard library model against the standard library of the
+// Go version in use: every stub must resolve to an existing site that can be nil, and no site is
+// annotated more than once. The test is skipped if the standard library cannot be loaded (e.g.,
+// the toolchain source is unavailable).
+func TestStdlibModel(t *testing.T) {
+	t.Parallel()
+
+	stubs := parseStdlibModel(t)
+	var paths []string
+	for _, s := range stubs {
+		paths = append(paths, s.Package)
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, paths...)
+	if err != nil {
+		t.Skipf("cannot load the standard library: %v", err)
+	}
+	byPath := make(map[string]*types.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			t.Skipf("cannot load the standard library package %q: %v", pkg.PkgPath, pkg.Errors)
+		}
+		byPath[pkg.PkgPath] = pkg.Types
+	}
+
+	checkStdlibModel(t, stubs, byPath, runtime.Version())
+}
+
+// TestStdlibModel_StubPackages checks the bundled standard library model against the stub
+// packages in testdata, which mirror the modeled declarations of the standard library, such that
+// the model is checked even if the standard library cannot be loaded.
+func TestStdlibModel_StubPackages(t *testing.T) {
+	t.Parallel()
+
+	stubs := parseStdlibModel(t)
+	byPath := make(map[string]*types.Package)
+	for _, s := range stubs {
+		if _, ok := byPath[s.Package]; !ok {
+			byPath[s.Package] = loadStdlibStub(t, s.Package)
+		}
+	}
+
+	checkStdlibModel(t, stubs, byPath, "the stub packages")
+}
+
+// parseStdlibModel parses the bundled standard library model and checks that it is versioned and
+// no site is annotated more than once.
+func parseStdlibModel(t *testing.T) []*Stub {
+	var f stubFile
+	require.NoError(t, yaml.Unmarshal(_stdlibModel, &f))
+	require.NotEmpty(t, f.Version, "the standard library model must be versioned")
+
+	stubs, err := parseStubs(_stdlibModelPath, _stdlibModel)
+	require.NoError(t, err)
+	require.NotEmpty(t, stubs)
+
+	seen := make(map[string]bool)
+	for _, s := range stubs {
+		require.False(t, seen[s.site()], "%s: duplicate stub for %q", s.position, s.site())
+		seen[s.site()] = true
+	}
+	return stubs
+}
+
+// checkStdlibModel checks that every stub resolves to an existing site that can be nil in the
+// given packages (described by where for the failure messages).
+func checkStdlibModel(t *testing.T, stubs []*Stub, byPath map[string]*types.Package, where string) {
+	for _, s := range stubs {
+		pkg, ok := byPath[s.Package]
+		require.True(t, ok, "%s: package %q not found in %s", s.position, s.Package, where)
+		key := s.key(pkg)
+		require.NotNil(t, key, "%s: %q not found in %s", s.position, s.site(), where)
+
+		// The underlying type also unwraps the aliases (e.g., `any`).
+		typ := stubSiteType(s, key).Underlying()
+		if s.Deep {
+			require.True(t, util.TypeIsDeep(typ), "%s: %q of type %s cannot be deeply annotated", s.position, s.site(), typ)
+		} else {
+			require.False(t, util.TypeBarsNilness(typ), "%s: %q of type %s can never be nil", s.position, s.site(), typ)
+		}
+	}
+}
+
+// loadStdlibStub type-checks the stub package in testdata that mirrors the standard library
+// package with the given path.
+func loadStdlibStub(t *testing.T, path string) *types.Package {
+	dir := filepath.Join("testdata", "stdlib", filepath.FromSlash(path))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "stub package for %q not found", path)
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, 0)
+		require.NoError(t, err)
+		files = append(files, file)
+	}
+	pkg, err := (&types.Config{}).Check(path, fset, files, nil)
+	require.NoError(t, err, "type-checking stub package for %q", path)
+	return pkg
+}
+
+// stubSiteType returns the type of the site annotated by the stub.
+func stubSiteType(s *Stub, key Key) types.Type {
+	if s.Func == "" {
+		return key.Object().Type()
+	}
+	sig := key.Object().Type().(*types.Signature)
+	switch {
+	case s.Param != nil:
+		return sig.Params().At(*s.Param).Type()
+	case s.Result != nil:
+		return sig.Results().At(*s.Result).Type()
+	default:
+		return sig.Recv().Type()
+	}
+}
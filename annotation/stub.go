@@ -0,0 +1,343 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Stub is a nilability annotation declared in an external stub file, which makes it possible to
+// annotate the sites in the packages whose source code cannot be modified (e.g., third-party or
+// standard library packages). A stub annotates exactly one site:
+//   - the parameter (Param), result (Result), or receiver (Receiver) of the function Func;
+//   - the struct field Field;
+//   - the global variable Global.
+//
+// Functions and fields are referred to as "<Name>" or "<Type>.<Name>" (for methods and fields). A
+// stub only overrides the default nilability of the site, i.e., the annotation in the source code
+// takes precedence if the site is annotated (see inference.Engine.ObserveAnnotations).
+type Stub struct {
+	// Package is the path of the package the site resides in.
+	Package  string `yaml:"package"`
+	Func     string `yaml:"func"`
+	Param    *int   `yaml:"param"`
+	Result   *int   `yaml:"result"`
+	Receiver bool   `yaml:"receiver"`
+	Field    string `yaml:"field"`
+	Global   string `yaml:"global"`
+	// Nilable is the nilability of the site.
+	Nilable *bool `yaml:"nilable"`
+	// Deep indicates that the stub annotates the deep nilability (e.g., the elements of a slice)
+	// of the site instead.
+	Deep bool `yaml:"deep"`
+
+	// position is the position of the stub in the stub file.
+	position token.Position
+}
+
+// stubFile is the content of a stub file.
+type stubFile struct {
+	// Version is the version of the stub file, which is only used by the bundled standard
+	// library model for now.
+	Version     string      `yaml:"version"`
+	Annotations []yaml.Node `yaml:"annotations"`
+}
+
+// Stubs are the stubs loaded from the stub files, grouped by the package paths.
+type Stubs struct {
+	byPkg map[string][]*Stub
+
+	// mu guards stubPkgs.
+	mu sync.Mutex
+	// stubPkgs memoizes the packages with stubs among each package and its transitive imports
+	// (see Stubs.stubPackages), such that the import graph is only walked once for all packages.
+	stubPkgs map[*types.Package][]*types.Package
+}
+
+// _stdlibModel is the bundled nilability model of the Go standard library, which is in the same
+// format as the stub files.
+//
+//go:embed stdlib.yaml
+var _stdlibModel []byte
+
+// _stdlibModelPath is the path of the bundled standard library model, used in the positions of
+// its stubs.
+const _stdlibModelPath = "go.uber.org/nilaway/annotation/stdlib.yaml"
+
+// _stubsCache caches the loaded stubs (as *stubsCacheEntry) by the stub file paths (and whether
+// the standard library model is included), such that the stub files are only loaded once instead
+// of for every analyzed package.
+var _stubsCache sync.Map
+
+// stubsCacheEntry is a cached load of the stub files.
+type stubsCacheEntry struct {
+	// version is made of the modification times and sizes of the stub files, such that the changed
+	// stub files are reloaded (e.g., by long-running drivers such as gopls) and replace the entry.
+	version string
+	load    func() (*Stubs, error)
+}
+
+// LoadStubs loads the stubs from the stub files, which are in YAML (or JSON, which is a subset of
+// YAML) format:
+//
+//	annotations:
+//	  - package: net/http
+//	    func: Client.Do
+//	    result: 0
+//	    nilable: false
+//	  - package: net/url
+//	    field: URL.User
+//	    nilable: true
+//
+// If stdlib is true, the bundled nilability model of the Go standard library is loaded first. A
+// stub takes precedence over the earlier ones (including the ones in the standard library model)
+// that annotate the same site.
+func LoadStubs(paths []string, stdlib bool) (*Stubs, error) {
+	load := func() (*Stubs, error) {
+		var all []*Stub
+		if stdlib {
+			parsed, err := parseStubs(_stdlibModelPath, _stdlibModel)
+			if err != nil {
+				return nil, fmt.Errorf("parse standard library model: %w", err)
+			}
+			all = append(all, parsed...)
+		}
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read stub file %q: %w", path, err)
+			}
+			parsed, err := parseStubs(path, content)
+			if err != nil {
+				return nil, fmt.Errorf("parse stub file %q: %w", path, err)
+			}
+			all = append(all, parsed...)
+		}
+
+		// Only keep the last stub for each site.
+		last := make(map[string]int, len(all))
+		for i, s := range all {
+			last[s.site()] = i
+		}
+		stubs := &Stubs{byPkg: make(map[string][]*Stub), stubPkgs: make(map[*types.Package][]*types.Package)}
+		for i, s := range all {
+			if last[s.site()] == i {
+				stubs.byPkg[s.Package] = append(stubs.byPkg[s.Package], s)
+			}
+		}
+		return stubs, nil
+	}
+
+	var versions []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// Do not cache the failures, load directly to report the error.
+			return load()
+		}
+		versions = append(versions, fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size()))
+	}
+	cacheKey := fmt.Sprintf("%t;%s", stdlib, strings.Join(paths, ","))
+	entry := &stubsCacheEntry{version: strings.Join(versions, ","), load: sync.OnceValues(load)}
+	if cached, loaded := _stubsCache.LoadOrStore(cacheKey, entry); loaded {
+		if c := cached.(*stubsCacheEntry); c.version == entry.version {
+			entry = c
+		} else {
+			_stubsCache.Store(cacheKey, entry)
+		}
+	}
+	return entry.load()
+}
+
+// parseStubs parses and validates the stubs in the content of the stub file.
+func parseStubs(path string, content []byte) ([]*Stub, error) {
+	var f stubFile
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, err
+	}
+
+	var stubs []*Stub
+	for _, node := range f.Annotations {
+		s := &Stub{}
+		if err := node.Decode(s); err != nil {
+			return nil, err
+		}
+		s.position = token.Position{Filename: path, Line: node.Line, Column: node.Column}
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.position, err)
+		}
+		stubs = append(stubs, s)
+	}
+	return stubs, nil
+}
+
+// validate checks that the stub annotates exactly one site.
+func (s *Stub) validate() error {
+	if s.Package == "" {
+		return errors.New("missing package")
+	}
+	if s.Nilable == nil {
+		return errors.New("missing nilable")
+	}
+
+	count := func(conds ...bool) int {
+		n := 0
+		for _, c := range conds {
+			if c {
+				n++
+			}
+		}
+		return n
+	}
+	if count(s.Func != "", s.Field != "", s.Global != "") != 1 {
+		return errors.New("expect exactly one of func, field, and global")
+	}
+	if s.Func != "" && count(s.Param != nil, s.Result != nil, s.Receiver) != 1 {
+		return errors.New("expect exactly one of param, result, and receiver for func")
+	}
+	if s.Func == "" && (s.Param != nil || s.Result != nil || s.Receiver) {
+		return errors.New("param, result, and receiver are only allowed for func")
+	}
+	if (s.Param != nil && *s.Param < 0) || (s.Result != nil && *s.Result < 0) {
+		return errors.New("negative param or result index")
+	}
+	return nil
+}
+
+// site returns the string representation of the site annotated by the stub, such that the stubs
+// annotating the same site have the same representation.
+func (s *Stub) site() string {
+	repr := s.Package + "."
+	switch {
+	case s.Global != "":
+		repr += s.Global
+	case s.Field != "":
+		repr += s.Field
+	case s.Param != nil:
+		repr += fmt.Sprintf("%s param %d", s.Func, *s.Param)
+	case s.Result != nil:
+		repr += fmt.Sprintf("%s result %d", s.Func, *s.Result)
+	case s.Receiver:
+		repr += s.Func + " receiver"
+	}
+	if s.Deep {
+		repr += " (deep)"
+	}
+	return repr
+}
+
+// Range calls op on each stub that annotates a site in the given package or its (transitive)
+// imports, along with the position of the stub in the stub file. Since the stub files are shared
+// by all packages, the stubs that cannot be resolved (e.g., annotating the sites in packages that
+// are not imported) are simply ignored.
+func (s *Stubs) Range(pkg *types.Package, op func(key Key, isDeep bool, val bool, position token.Position)) {
+	if s == nil || len(s.byPkg) == 0 {
+		return
+	}
+
+	for _, p := range s.stubPackages(pkg) {
+		for _, stub := range s.byPkg[p.Path()] {
+			if key := stub.key(p); key != nil {
+				op(key, stub.Deep, *stub.Nilable, stub.position)
+			}
+		}
+	}
+}
+
+// stubPackages returns the packages with stubs among the given package and its transitive imports.
+// The results are memoized for all packages in the import graph, since the imported packages are
+// shared by the analyzed packages.
+func (s *Stubs) stubPackages(pkg *types.Package) []*types.Package {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var visit func(p *types.Package) []*types.Package
+	visit = func(p *types.Package) []*types.Package {
+		if pkgs, ok := s.stubPkgs[p]; ok {
+			return pkgs
+		}
+		// Mark the package as visited first to break the (invalid) import cycles.
+		s.stubPkgs[p] = nil
+
+		var pkgs []*types.Package
+		if _, ok := s.byPkg[p.Path()]; ok {
+			pkgs = append(pkgs, p)
+		}
+		for _, imported := range p.Imports() {
+			for _, q := range visit(imported) {
+				if !slices.Contains(pkgs, q) {
+					pkgs = append(pkgs, q)
+				}
+			}
+		}
+		s.stubPkgs[p] = pkgs
+		return pkgs
+	}
+	return visit(pkg)
+}
+
+// key resolves the site annotated by the stub in the package, or nil if it cannot be resolved.
+func (s *Stub) key(pkg *types.Package) Key {
+	switch {
+	case s.Global != "":
+		if v, ok := pkg.Scope().Lookup(s.Global).(*types.Var); ok {
+			return &GlobalVarAnnotationKey{VarDecl: v}
+		}
+	case s.Field != "":
+		if v, ok := lookupMember(pkg, s.Field).(*types.Var); ok && v.IsField() {
+			return &FieldAnnotationKey{FieldDecl: v}
+		}
+	case s.Func != "":
+		fn, ok := lookupMember(pkg, s.Func).(*types.Func)
+		if !ok {
+			return nil
+		}
+		sig := fn.Type().(*types.Signature)
+		switch {
+		case s.Param != nil && *s.Param < sig.Params().Len():
+			return ParamKeyFromArgNum(fn, *s.Param)
+		case s.Result != nil && *s.Result < sig.Results().Len():
+			return RetKeyFromRetNum(fn, *s.Result)
+		case s.Receiver && sig.Recv() != nil:
+			return &RecvAnnotationKey{FuncDecl: fn}
+		}
+	}
+	return nil
+}
+
+// lookupMember looks up the package-level object ("<Name>") or the field or method of a named
+// type ("<Type>.<Name>") in the package.
+func lookupMember(pkg *types.Package, name string) types.Object {
+	typeName, member, ok := strings.Cut(name, ".")
+	if !ok {
+		return pkg.Scope().Lookup(name)
+	}
+	tn, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true /* addressable */, pkg, member)
+	return obj
+}
@@ -0,0 +1,170 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const _testStubSrc = `package pkg
+
+var Global *int
+
+type T struct {
+	f *int
+}
+
+func (t *T) Method(a, b *int) *int { return nil }
+
+func Func() (*int, error) { return nil, nil }
+`
+
+func TestLoadStubs(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yamlPath, jsonPath := filepath.Join(dir, "stubs.yaml"), filepath.Join(dir, "stubs.json")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`annotations:
+  - package: example.com/pkg
+    func: Func
+    result: 0
+    nilable: true
+  - package: example.com/pkg
+    func: T.Method
+    param: 1
+    nilable: false
+  - package: example.com/pkg
+    func: T.Method
+    receiver: true
+    nilable: false
+  - package: example.com/pkg
+    field: T.f
+    nilable: true
+    deep: true
+  # Unresolvable stubs are ignored.
+  - package: example.com/pkg
+    func: T.Method
+    param: 2
+    nilable: true
+  - package: example.com/pkg
+    field: T.missing
+    nilable: true
+`), 0o644))
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"annotations": [
+  {"package": "example.com/pkg", "global": "Global", "nilable": true},
+  {"package": "example.com/other", "global": "Global", "nilable": true},
+  {"package": "example.com/pkg", "func": "Func", "result": 0, "nilable": false}
+]}`), 0o644))
+
+	stubs, err := LoadStubs([]string{yamlPath, jsonPath}, false /* stdlib */)
+	require.NoError(t, err)
+	// The stubs should be cached.
+	cached, err := LoadStubs([]string{yamlPath, jsonPath}, false /* stdlib */)
+	require.NoError(t, err)
+	require.Same(t, stubs, cached)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pkg.go", _testStubSrc, 0)
+	require.NoError(t, err)
+	pkg, err := new(types.Config).Check("example.com/pkg", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+
+	var got []string
+	stubs.Range(pkg, func(key Key, isDeep bool, val bool, position token.Position) {
+		repr := key.String()
+		if isDeep {
+			repr = "Deep " + repr
+		}
+		if val {
+			repr += " nilable"
+		} else {
+			repr += " nonnil"
+		}
+		got = append(got, filepath.Base(position.String())+" "+repr)
+	})
+	// The later stubs take precedence over the earlier ones annotating the same site.
+	require.Equal(t, []string{
+		"stubs.yaml:6:5 Param 1: 'b' of Function Method nonnil",
+		"stubs.yaml:10:5 Receiver of Method Method nonnil",
+		"stubs.yaml:14:5 Deep Field f nilable",
+		"stubs.json:2:3 Global Variable Global nilable",
+		"stubs.json:4:3 Result 0 of Function Func nonnil",
+	}, got)
+
+	// Only the packages with stubs among the transitive imports are visited.
+	mid, root := types.NewPackage("example.com/mid", "mid"), types.NewPackage("example.com/root", "root")
+	mid.SetImports([]*types.Package{pkg})
+	root.SetImports([]*types.Package{mid, pkg})
+	require.Equal(t, []*types.Package{pkg}, stubs.stubPackages(root))
+	count := 0
+	stubs.Range(root, func(Key, bool, bool, token.Position) { count++ })
+	require.Len(t, got, count)
+}
+
+func TestLoadStubs_Reload(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "stubs.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`annotations: []`), 0o644))
+	stubs, err := LoadStubs([]string{path}, false /* stdlib */)
+	require.NoError(t, err)
+	require.Empty(t, stubs.byPkg)
+
+	// The changed stub files should be reloaded instead of served from the cache.
+	require.NoError(t, os.WriteFile(path, []byte(`annotations: [{package: pkg, global: Foo, nilable: true}]`), 0o644))
+	mtime := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+	reloaded, err := LoadStubs([]string{path}, false /* stdlib */)
+	require.NoError(t, err)
+	require.NotSame(t, stubs, reloaded)
+	require.Len(t, reloaded.byPkg["pkg"], 1)
+
+	cached, err := LoadStubs([]string{path}, false /* stdlib */)
+	require.NoError(t, err)
+	require.Same(t, reloaded, cached)
+}
+
+func TestLoadStubs_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := LoadStubs([]string{filepath.Join(t.TempDir(), "missing.yaml")}, false /* stdlib */)
+	require.ErrorContains(t, err, "read stub file")
+
+	for _, invalid := range []string{
+		`annotations: foo`,
+		`annotations: [{func: Foo, result: 0, nilable: true}]`,
+		`annotations: [{package: pkg, func: Foo, result: 0}]`,
+		`annotations: [{package: pkg, func: Foo, nilable: true}]`,
+		`annotations: [{package: pkg, func: Foo, result: 0, param: 0, nilable: true}]`,
+		`annotations: [{package: pkg, func: Foo, field: T.f, result: 0, nilable: true}]`,
+		`annotations: [{package: pkg, field: T.f, result: 0, nilable: true}]`,
+		`annotations: [{package: pkg, func: Foo, result: -1, nilable: true}]`,
+		`annotations: [{package: pkg, global: Foo, nilable: maybe}]`,
+	} {
+		path := filepath.Join(t.TempDir(), "stubs.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(invalid), 0o644))
+		_, err := LoadStubs([]string{path}, false /* stdlib */)
+		require.ErrorContains(t, err, "parse stub file", invalid)
+	}
+}
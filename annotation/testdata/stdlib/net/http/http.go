@@ -0,0 +1,42 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package http mirrors the declarations of the standard library package of the same path that
+// are modeled in the bundled standard library model (see TestStdlibModel).
+package http
+
+type (
+	URL             struct{}
+	Header          map[string][]string
+	ConnectionState struct{}
+	Form            struct{}
+	Client          struct{}
+	ServeMux        struct{}
+)
+
+type Request struct {
+	URL           *URL
+	Header        Header
+	TLS           *ConnectionState
+	Response      *Response
+	MultipartForm *Form
+}
+
+type Response struct {
+	TLS *ConnectionState
+}
+
+var DefaultClient = &Client{}
+
+var DefaultServeMux = &ServeMux{}
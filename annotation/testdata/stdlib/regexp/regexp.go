@@ -0,0 +1,27 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package regexp mirrors the declarations of the standard library package of the same path that
+// are modeled in the bundled standard library model (see TestStdlibModel).
+package regexp
+
+type Regexp struct{}
+
+func (re *Regexp) FindSubmatch(b []byte) [][]byte { return nil }
+
+func (re *Regexp) FindStringSubmatch(s string) []string { return nil }
+
+func (re *Regexp) FindStringIndex(s string) []int { return nil }
+
+func (re *Regexp) FindStringSubmatchIndex(s string) []int { return nil }
@@ -57,6 +57,39 @@ func DeepNilabilityAsNamedType(typ types.Type) ProducingAnnotationTrigger {
 	return &ProduceTriggerNever{}
 }
 
+// ElemProducer returns the deep producer of a value of the given type, which is read from the
+// elements of a container whose deep producer is passed, e.g., the producer of the elements of
+// `x[i]` given the producer of the elements of `x`. The nested elements of the sites are tracked
+// by the element keys (see ElemAnnotationKey), while the nested elements of the named types are
+// annotated by the named types (see DeepNilabilityAsNamedType). Same as the default deep
+// nilability, the multi-dimensional arrays are flattened such that the elements of an array
+// element share the nilability of the array element.
+func ElemProducer(p ProducingAnnotationTrigger, typ types.Type) ProducingAnnotationTrigger {
+	if _, ok := typ.(*types.Named); ok {
+		return DeepNilabilityAsNamedType(typ)
+	}
+	switch typ.Underlying().(type) {
+	case *types.Array:
+		return p
+	case *types.Slice, *types.Map, *types.Pointer, *types.Chan:
+	default:
+		return &ProduceTriggerNever{}
+	}
+	if p.Kind() != DeepConditional {
+		return &ProduceTriggerNever{}
+	}
+	site := p.UnderlyingSite()
+	if _, ok := site.(*TypeNameAnnotationKey); ok {
+		return &ProduceTriggerNever{}
+	}
+	return &ElemRead{
+		TriggerIfDeepNilable: &TriggerIfDeepNilable{
+			Ann:        ElemKeyOf(site),
+			NeedsGuard: util.TypeIsDeeplyMap(typ),
+		},
+	}
+}
+
 // DeepNilabilityOfFuncRet inspects a function return for deep nilability annotation
 func DeepNilabilityOfFuncRet(fn *types.Func, retNum int) ProducingAnnotationTrigger {
 	fsig := fn.Type().(*types.Signature)
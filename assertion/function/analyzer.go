@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 	"reflect"
 	"runtime/debug"
@@ -279,6 +280,26 @@ func duplicateFullTriggersFromContractedFunctionsToCallers(
 	// return) into all the callers
 	dupTriggers := map[*types.Func][]annotation.FullTrigger{}
 	for ctrtFunc, calls := range callsByCtrtFunc {
+		ctrs, _ := funcContracts.Duplicable(ctrtFunc)
+		// The nil arguments flow to the results guaranteed to be nil by the contracts at every
+		// call site, regardless of where the contracted function is.
+		for caller, callExprs := range calls {
+			for _, callExpr := range callExprs {
+				dupTriggers[caller] = append(dupTriggers[caller],
+					nilFlowTriggers(ctrtFunc, ctrs, callExpr, pass)...)
+			}
+		}
+		if ctrtFunc.Pkg() != pass.Pkg {
+			// The body of an upstream contracted function is not available in this package, so we
+			// instantiate its contracts at every call site instead.
+			for caller, callExprs := range calls {
+				for _, callExpr := range callExprs {
+					dupTriggers[caller] = append(dupTriggers[caller],
+						instantiateUpstreamContract(ctrtFunc, ctrs, callExpr, pass)...)
+				}
+			}
+			continue
+		}
 		r := funcResults[ctrtFunc]
 		if r == nil {
 			// should not happen since funcResults should contain all the functions including any
@@ -298,7 +319,7 @@ func duplicateFullTriggersFromContractedFunctionsToCallers(
 			// Duplicate the full trigger in every caller
 			for caller, callExprs := range calls {
 				for _, callExpr := range callExprs {
-					dupTrigger := duplicateFullTrigger(trigger, ctrtFunc, callExpr, pass,
+					dupTrigger := duplicateFullTrigger(trigger, ctrtFunc, ctrs, callExpr, pass,
 						isParamProducer, isReturnConsumer)
 
 					// Store the duplicated full trigger
@@ -321,19 +342,22 @@ func duplicateFullTriggersFromContractedFunctionsToCallers(
 }
 
 // duplicateFullTrigger creates a (possibly controlled) full trigger from the given full trigger
-// with FuncParam producer or UseAsReturn consumer or both.
+// with FuncParam producer or UseAsReturn consumer or both. The duplicated full triggers returning
+// the results guaranteed to be nonnil by the contracts are controlled by the argument of the
+// parameter required to be nonnil (see functioncontracts.ControllingParam).
 // Precondition: isParamProducer or isReturnConsumer is true; also they can be both true.
 func duplicateFullTrigger(
 	trigger annotation.FullTrigger,
 	callee *types.Func,
+	ctrs []*functioncontracts.FunctionContract,
 	callExpr *ast.CallExpr,
 	pass *analysis.Pass,
 	isParamProducer bool,
 	isReturnConsumer bool,
 ) annotation.FullTrigger {
-	// TODO: what if we have more than one parameter, planned in future revisions
-	argExpr := callExpr.Args[0]
-	argLoc := util.PosToLocation(argExpr.Pos(), pass)
+	argLocOf := func(i int) token.Position {
+		return util.PosToLocation(callExpr.Args[i].Pos(), pass)
+	}
 
 	// Create the duplicated full trigger
 	// TODO: we just copy the pointer for producer and consumer because I don't see a problem when
@@ -352,19 +376,133 @@ func duplicateFullTrigger(
 		CreatedFromDuplication: true,
 	}
 	if isParamProducer {
-		dupTrigger.Producer = annotation.DuplicateParamProducer(trigger.Producer, argLoc)
+		key := trigger.Producer.Annotation.(*annotation.FuncParam).Ann.(*annotation.ParamAnnotationKey)
+		dupTrigger.Producer = annotation.DuplicateParamProducer(trigger.Producer, argLocOf(key.ParamNum))
 	}
 	if isReturnConsumer {
 		retLoc := util.PosToLocation(callExpr.Pos(), pass)
 		dupTrigger.Consumer = annotation.DuplicateReturnConsumer(trigger.Consumer, retLoc)
-		// Set up the site that controls the controlled full trigger to be created
-		c := annotation.NewCallSiteParamKey(callee, 0, argLoc)
-		dupTrigger.Controller = c
+		// Set up the site that controls the controlled full trigger to be created, the results
+		// not guaranteed by the contract are returned regardless of the arguments.
+		key := trigger.Consumer.Annotation.(*annotation.UseAsReturn).Ann.(*annotation.RetAnnotationKey)
+		if param, ok := functioncontracts.ControllingParam(ctrs, key.RetNum); ok {
+			dupTrigger.Controller = annotation.NewCallSiteParamKey(callee, param, argLocOf(param))
+		}
 	}
 
 	return dupTrigger
 }
 
+// instantiateUpstreamContract creates the full triggers for a call to a contracted function from
+// an upstream package, whose full triggers are not available for duplication. Instead, every
+// argument site at the call flows into the parameter site of the function, and the result site
+// of the function flows into every result site at the call, where the results guaranteed to be
+// nonnil by the contracts are controlled by the argument of the parameter required to be nonnil.
+func instantiateUpstreamContract(
+	callee *types.Func,
+	ctrs []*functioncontracts.FunctionContract,
+	callExpr *ast.CallExpr,
+	pass *analysis.Pass,
+) []annotation.FullTrigger {
+	argLocOf := func(i int) token.Position {
+		return util.PosToLocation(callExpr.Args[i].Pos(), pass)
+	}
+
+	var triggers []annotation.FullTrigger
+	sig := callee.Type().(*types.Signature)
+	for i := 0; i < sig.Params().Len(); i++ {
+		if util.TypeBarsNilness(sig.Params().At(i).Type()) {
+			continue
+		}
+		triggers = append(triggers, annotation.FullTrigger{
+			Producer: &annotation.ProduceTrigger{
+				Annotation: &annotation.FuncParam{
+					TriggerIfNilable: &annotation.TriggerIfNilable{
+						Ann: annotation.NewCallSiteParamKey(callee, i, argLocOf(i))}},
+				Expr: callExpr.Args[i],
+			},
+			Consumer: &annotation.ConsumeTrigger{
+				Annotation: &annotation.ArgPass{
+					TriggerIfNonNil: &annotation.TriggerIfNonNil{
+						Ann: annotation.ParamKeyFromArgNum(callee, i)}},
+				Expr:   callExpr.Args[i],
+				Guards: util.NoGuards(),
+			},
+			CreatedFromDuplication: true,
+		})
+	}
+
+	retLoc := util.PosToLocation(callExpr.Pos(), pass)
+	for i := 0; i < sig.Results().Len(); i++ {
+		if util.TypeBarsNilness(sig.Results().At(i).Type()) {
+			continue
+		}
+		trigger := annotation.FullTrigger{
+			Producer: &annotation.ProduceTrigger{
+				Annotation: &annotation.FuncReturn{
+					TriggerIfNilable: &annotation.TriggerIfNilable{
+						Ann: annotation.RetKeyFromRetNum(callee, i)}},
+				Expr: callExpr,
+			},
+			Consumer: &annotation.ConsumeTrigger{
+				Annotation: &annotation.UseAsReturn{
+					TriggerIfNonNil: &annotation.TriggerIfNonNil{
+						Ann: annotation.NewCallSiteRetKey(callee, i, retLoc)}},
+				Expr:   callExpr,
+				Guards: util.NoGuards(),
+			},
+			CreatedFromDuplication: true,
+		}
+		if param, ok := functioncontracts.ControllingParam(ctrs, i); ok {
+			trigger.Controller = annotation.NewCallSiteParamKey(callee, param, argLocOf(param))
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers
+}
+
+// nilFlowTriggers creates the full triggers for a call to a contracted function, such that the
+// argument of the parameter required to be nil by a contract (e.g., `contract(nil -> nil)`) flows
+// into the results guaranteed to be nil by the contract at the call site.
+func nilFlowTriggers(
+	callee *types.Func,
+	ctrs []*functioncontracts.FunctionContract,
+	callExpr *ast.CallExpr,
+	pass *analysis.Pass,
+) []annotation.FullTrigger {
+	var triggers []annotation.FullTrigger
+	retLoc := util.PosToLocation(callExpr.Pos(), pass)
+	for _, ctr := range ctrs {
+		param, ok := ctr.NilParam()
+		if !ok {
+			continue
+		}
+		argLoc := util.PosToLocation(callExpr.Args[param].Pos(), pass)
+		for i, isNil := range ctr.NilResults() {
+			if !isNil {
+				continue
+			}
+			triggers = append(triggers, annotation.FullTrigger{
+				Producer: &annotation.ProduceTrigger{
+					Annotation: &annotation.FuncParam{
+						TriggerIfNilable: &annotation.TriggerIfNilable{
+							Ann: annotation.NewCallSiteParamKey(callee, param, argLoc)}},
+					Expr: callExpr.Args[param],
+				},
+				Consumer: &annotation.ConsumeTrigger{
+					Annotation: &annotation.UseAsReturn{
+						TriggerIfNonNil: &annotation.TriggerIfNonNil{
+							Ann: annotation.NewCallSiteRetKey(callee, i, retLoc)}},
+					Expr:   callExpr,
+					Guards: util.NoGuards(),
+				},
+				CreatedFromDuplication: true,
+			})
+		}
+	}
+	return triggers
+}
+
 // findCallsToContractedFunctions finds all the calls to the contracted functions in the given
 // function, and returns a map from every called contracted function to the call expressions that
 // call it.
@@ -390,11 +528,17 @@ func findCallsToContractedFunctions(
 			return true
 		}
 
-		// TODO: for now we find the functions with only a single contract nonnil -> nonnil. If we
-		//  want to support multiple contracts or contracts with multiple/other values not only we
-		//  should update here, but we should also make changes to other parts of duplicating
-		//  triggers.
-		if !hasOnlyNonNilToNonNilContract(functionContracts, funcObj) {
+		// TODO: for now we find the functions with at most one contract that requires one
+		//  parameter to be nonnil, and any contracts that require one parameter to be nil (see
+		//  functioncontracts.Map.Duplicable). If we want to support other forms of contracts
+		//  (e.g., disjunctive ones) not only we should update here, but we should also make
+		//  changes to other parts of duplicating triggers.
+		if _, ok := functionContracts.Duplicable(funcObj); !ok {
+			return true
+		}
+		// The arguments are matched to the parameters, which is not possible for calls like
+		// `f(g())` where g returns multiple values.
+		if len(callExpr.Args) != funcObj.Type().(*types.Signature).Params().Len() {
 			return true
 		}
 		calls[funcObj] = append(calls[funcObj], callExpr)
@@ -403,18 +547,6 @@ func findCallsToContractedFunctions(
 	return calls
 }
 
-// hasOnlyNonNilToNonNilContract returns whether the given function has only one contract that is
-// nonnil->nonnil.
-func hasOnlyNonNilToNonNilContract(funcContracts functioncontracts.Map, funcObj *types.Func) bool {
-	contracts, ok := funcContracts[funcObj]
-	if !ok || len(contracts) != 1 {
-		return false
-	}
-	ctr := contracts[0]
-	return len(ctr.Ins) == 1 && ctr.Ins[0] == functioncontracts.NonNil &&
-		len(ctr.Outs) == 1 && ctr.Outs[0] == functioncontracts.NonNil
-}
-
 // analyzeFunc analyzes a given function declaration and emit generated triggers, or an error if
 // something went wrong during the analysis. It is mainly a wrapper function for
 // assertiontree.BackpropAcrossFunc with synchronization and communication support for concurrency.
@@ -24,9 +24,11 @@ import (
 
 	"go.uber.org/nilaway/annotation"
 	"go.uber.org/nilaway/assertion/function/trustedfunc"
+	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/util"
 	"go.uber.org/nilaway/util/asthelper"
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/cfg"
 )
 
@@ -260,7 +262,7 @@ func isErrorReturnNonnil(rootNode *RootAssertionNode, errRet ast.Expr) bool {
 //
 // Note that `results` should be explicitly passed since `retStmt` of a named return will contain no results
 func handleErrorReturns(rootNode *RootAssertionNode, retStmt *ast.ReturnStmt, results []ast.Expr, isNamedReturn bool) bool {
-	if !util.FuncIsErrReturning(rootNode.FuncObj()) {
+	if !rootNode.funcIsErrReturning(rootNode.FuncObj()) {
 		return false
 	}
 
@@ -311,7 +313,7 @@ func handleErrorReturns(rootNode *RootAssertionNode, retStmt *ast.ReturnStmt, re
 func handleBooleanReturns(rootNode *RootAssertionNode, retStmt *ast.ReturnStmt, results []ast.Expr, isNamedReturn bool) bool {
 	// FuncIsOkReturning checks that the length of the results defined for the current function is at least 2, and that
 	// the last return type is a boolean, the value of which can be determined at compile time (e.g., return true)
-	if !util.FuncIsOkReturning(rootNode.FuncObj()) {
+	if !rootNode.funcIsOkReturning(rootNode.FuncObj()) {
 		return false
 	}
 
@@ -526,7 +528,14 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 					}, nil
 				}
 			case *ast.IndexExpr:
+				if consumer := elemAssignConsumer(rootNode, expr); consumer != nil {
+					return consumer, nil
+				}
 				return exprAsAssignmentConsumer(rootNode, expr.X, exprRHS)
+			case *ast.StarExpr:
+				if consumer := elemAssignConsumer(rootNode, expr); consumer != nil {
+					return consumer, nil
+				}
 			}
 
 			nameAsDeepTrigger := func(name *types.TypeName) *annotation.TriggerIfDeepNonNil {
@@ -557,7 +566,8 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 			// to an annotation site, for example, local variables.
 			// so we introspect on its type alone
 
-			if !annotation.TypeIsDeepDefaultNilable(exprType) {
+			conf := rootNode.Pass().ResultOf[config.Analyzer].(*config.Config)
+			if !annotation.TypeIsDeepDefaultNilable(exprType, conf) {
 				if ident, ok := expr.(*ast.Ident); ok {
 					varObj := rootNode.ObjectOf(ident).(*types.Var)
 					return &annotation.LocalVarAssignDeep{
@@ -631,6 +641,131 @@ func exprAsAssignmentConsumer(rootNode *RootAssertionNode, expr ast.Node, exprRH
 	return nil, nil
 }
 
+// elemAssignConsumer returns the consumer for the deep assignments to the container `expr` (e.g.,
+// `x[i]` in `x[i][j] = v`) if it is a nested element of an annotation site (i.e., `x`), and nil
+// otherwise. The elements of the named types are annotated by the named types instead.
+func elemAssignConsumer(rootNode *RootAssertionNode, expr ast.Expr) annotation.ConsumingAnnotationTrigger {
+	if _, ok := rootNode.Pass().TypesInfo.TypeOf(expr).(*types.Named); ok {
+		return nil
+	}
+	key, depth := elemSite(rootNode, expr)
+	if key == nil || depth == 0 {
+		return nil
+	}
+	return &annotation.ElemAssign{
+		TriggerIfDeepNonNil: &annotation.TriggerIfDeepNonNil{
+			Ann: &annotation.ElemAnnotationKey{Base: key, Depth: depth},
+		},
+	}
+}
+
+// elemSite returns the annotation site that the expression is a nested element of, along with the
+// depth of the expression in it (e.g., `x` and 2 for `*x[i][j]` with `x` of type `[][]*int`). Nil
+// is returned if the expression is not a nested element of any site. Same as the default deep
+// nilability, the multi-dimensional arrays are flattened, hence reading an array element does not
+// increase the depth.
+func elemSite(rootNode *RootAssertionNode, expr ast.Expr) (annotation.Key, int) {
+	var x ast.Expr
+	switch e := astutil.Unparen(expr).(type) {
+	case *ast.IndexExpr:
+		x = e.X
+	case *ast.StarExpr:
+		x = e.X
+	default:
+		return rootSite(rootNode, expr), 0
+	}
+
+	key, depth := elemSite(rootNode, x)
+	if key == nil {
+		return nil, 0
+	}
+	if _, ok := rootNode.Pass().TypesInfo.TypeOf(x).(*types.Named); ok && depth > 0 {
+		return nil, 0
+	}
+	if _, ok := rootNode.Pass().TypesInfo.TypeOf(expr).Underlying().(*types.Array); ok {
+		return key, depth
+	}
+	return key, depth + 1
+}
+
+// rootSite returns the annotation site whose deep nilability is tracked by the sites of its nested
+// elements (see annotation.ElemAnnotationKey), i.e., the parameters (except the variadic ones),
+// global variables, fields and results of deep types, and nil if the expression is not such one.
+func rootSite(rootNode *RootAssertionNode, expr ast.Expr) annotation.Key {
+	typ := rootNode.Pass().TypesInfo.TypeOf(expr)
+	if typ == nil || !util.TypeIsDeep(typ) {
+		return nil
+	}
+
+	handleIdent := func(ident *ast.Ident) annotation.Key {
+		v, ok := rootNode.ObjectOf(ident).(*types.Var)
+		if !ok {
+			return nil
+		}
+		funcObj := rootNode.FuncObj()
+		switch {
+		case annotation.VarIsGlobal(v):
+			return &annotation.GlobalVarAnnotationKey{VarDecl: v}
+		case annotation.VarIsVariadicParam(funcObj, v):
+			return nil
+		case annotation.VarIsParam(funcObj, v):
+			return annotation.ParamKeyFromName(funcObj, v)
+		}
+		return nil
+	}
+
+	switch expr := astutil.Unparen(expr).(type) {
+	case *ast.Ident:
+		return handleIdent(expr)
+	case *ast.SelectorExpr:
+		if rootNode.isPkgName(expr.X) {
+			return handleIdent(expr.Sel)
+		}
+		if v, ok := rootNode.ObjectOf(expr.Sel).(*types.Var); ok && v.IsField() {
+			return &annotation.FieldAnnotationKey{FieldDecl: v}
+		}
+	case *ast.CallExpr:
+		if ident := util.FuncIdentFromCallExpr(expr); ident != nil {
+			if fn, ok := rootNode.ObjectOf(ident).(*types.Func); ok && util.FuncNumResults(fn) == 1 {
+				return annotation.RetKeyFromRetNum(fn, 0)
+			}
+		}
+	}
+	return nil
+}
+
+// addDeeperTriggers adds the full triggers that track the nilabilities of the nested elements of
+// `expr` beyond its elements (e.g., the elements of the slices in a `[][]*int` value) as it flows
+// deeply to the site of `key` of type `target`. The producer of the deep nilability of `expr` is
+// passed, and consumerOf creates the consumer for the key of the nested elements at each depth.
+func addDeeperTriggers(
+	rootNode *RootAssertionNode,
+	expr ast.Expr,
+	deepProducer annotation.ProducingAnnotationTrigger,
+	key annotation.Key,
+	target types.Type,
+	consumerOf func(elemKey annotation.Key) annotation.ConsumingAnnotationTrigger,
+) {
+	elems := annotation.ElemTypes(rootNode.Pass().TypesInfo.TypeOf(expr))
+	levels := min(len(elems), len(annotation.ElemTypes(target)))
+	producer := deepProducer
+	for depth := 2; depth < levels; depth++ {
+		producer = annotation.ElemProducer(producer, elems[depth-1])
+		if _, ok := producer.(*annotation.ProduceTriggerNever); ok {
+			return
+		}
+		key = annotation.ElemKeyOf(key)
+		consumer := consumerOf(key)
+		// since this is an implicit tracking of the nested elements of expr, we don't need to
+		// check for their guarding
+		consumer.SetNeedsGuard(false)
+		rootNode.AddNewTriggers(annotation.FullTrigger{
+			Producer: &annotation.ProduceTrigger{Annotation: producer, Expr: expr},
+			Consumer: &annotation.ConsumeTrigger{Annotation: consumer, Expr: expr, Guards: util.NoGuards()},
+		})
+	}
+}
+
 func composeRootFuncs(f1, f2 RootFunc) RootFunc {
 	return func(node *RootAssertionNode) {
 		f1(node)
@@ -853,5 +988,14 @@ func addReturnConsumers(rootNode *RootAssertionNode, node *ast.ReturnStmt, expr
 			Producer: producer,
 			Consumer: consumer,
 		})
+
+		retType := retKey.FuncDecl.Type().(*types.Signature).Results().At(retKey.RetNum).Type()
+		addDeeperTriggers(rootNode, expr, producer.Annotation, retKey, retType, func(elemKey annotation.Key) annotation.ConsumingAnnotationTrigger {
+			return &annotation.UseAsReturnDeep{
+				TriggerIfDeepNonNil: &annotation.TriggerIfDeepNonNil{Ann: elemKey},
+				IsNamedReturn:       isNamedReturn,
+				RetStmt:             node,
+			}
+		})
 	}
 }
@@ -166,10 +166,10 @@ func (r *RootAssertionNode) ParseExprAsProducer(expr ast.Expr, doNotTrack bool)
 					Annotation: rproducers[0].GetDeep().Annotation,
 					Expr:       expr,
 				},
-				// there is no possible source for a doubly deep nilability annotation except
-				// the named type of the expression
+				// the doubly deep nilability comes from the nested elements of the site of
+				// `deepExpr`, or the named type of the expression
 				DeepProducer: &annotation.ProduceTrigger{
-					Annotation: annotation.DeepNilabilityAsNamedType(r.Pass().TypesInfo.Types[expr].Type),
+					Annotation: annotation.ElemProducer(rproducers[0].GetDeep().Annotation, r.Pass().TypesInfo.Types[expr].Type),
 					Expr:       expr,
 				},
 			}}
@@ -448,8 +448,8 @@ func (r *RootAssertionNode) getFuncReturnProducers(ident *ast.Ident, expr *ast.C
 	funcObj := r.ObjectOf(ident).(*types.Func)
 
 	numResults := util.FuncNumResults(funcObj)
-	isErrReturning := util.FuncIsErrReturning(funcObj)
-	isOkReturning := util.FuncIsOkReturning(funcObj)
+	isErrReturning := r.funcIsErrReturning(funcObj)
+	isOkReturning := r.funcIsOkReturning(funcObj)
 
 	producers := make([]producer.ParsedProducer, numResults)
 
@@ -345,7 +345,7 @@ func NodeTriggersOkRead(rootNode *RootAssertionNode, nonceGenerator *util.GuardN
 
 		rhsFuncDecl, ok := rootNode.ObjectOf(callIdent).(*types.Func)
 
-		if !ok || !util.FuncIsOkReturning(rhsFuncDecl) {
+		if !ok || !rootNode.funcIsOkReturning(rhsFuncDecl) {
 			return nil, false
 		}
 
@@ -399,7 +399,7 @@ func NodeTriggersFuncErrRet(rootNode *RootAssertionNode, nonceGenerator *util.Gu
 
 	rhsFuncDecl, ok := rootNode.Pass().TypesInfo.ObjectOf(callIdent).(*types.Func)
 
-	if !ok || !util.FuncIsErrReturning(rhsFuncDecl) {
+	if !ok || !rootNode.funcIsErrReturning(rhsFuncDecl) {
 		return nil, false
 	}
 
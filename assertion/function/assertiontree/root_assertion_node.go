@@ -55,12 +55,25 @@ func (r *RootAssertionNode) LocationOf(expr ast.Expr) token.Position {
 	return util.PosToLocation(expr.Pos(), r.Pass())
 }
 
-// HasContract returns if the given function has any contracts.
+// HasContract returns if the given function has a contract that is applied at its call sites,
+// i.e., its full triggers are duplicated to the call sites (see functioncontracts.Map.Duplicable).
 func (r *RootAssertionNode) HasContract(funcObj *types.Func) bool {
-	_, ok := r.functionContext.funcContracts[funcObj]
+	_, ok := r.functionContext.funcContracts.Duplicable(funcObj)
 	return ok
 }
 
+// funcIsErrReturning returns if the given function is deemed "error-returning" by either its
+// signature (see util.FuncIsErrReturning) or its contracts (see functioncontracts.Map.IsErrReturning).
+func (r *RootAssertionNode) funcIsErrReturning(funcObj *types.Func) bool {
+	return util.FuncIsErrReturning(funcObj) || r.functionContext.funcContracts.IsErrReturning(funcObj)
+}
+
+// funcIsOkReturning returns if the given function is deemed "ok-returning" by either its
+// signature (see util.FuncIsOkReturning) or its contracts (see functioncontracts.Map.IsOkReturning).
+func (r *RootAssertionNode) funcIsOkReturning(funcObj *types.Func) bool {
+	return util.FuncIsOkReturning(funcObj) || r.functionContext.funcContracts.IsOkReturning(funcObj)
+}
+
 // MinimalString for a RootAssertionNode returns a minimal string representation of that root node
 func (r *RootAssertionNode) MinimalString() string {
 	return fmt.Sprintf("root<func: %s>", r.functionContext.funcDecl.Name)
@@ -712,6 +725,15 @@ func (r *RootAssertionNode) AddComputation(expr ast.Expr) {
 							Producer: deepProducer,
 							Consumer: deepConsumer,
 						})
+
+						// the nested elements of the non-variadic params are tracked as well
+						if params := fdecl.Type().(*types.Signature).Params(); i < params.Len() && !annotation.VarIsVariadicParam(fdecl, params.At(i)) {
+							addDeeperTriggers(r, arg, deepProducer.Annotation, paramKey, params.At(i).Type(), func(elemKey annotation.Key) annotation.ConsumingAnnotationTrigger {
+								return &annotation.ArgPassDeep{
+									TriggerIfDeepNonNil: &annotation.TriggerIfDeepNonNil{Ann: elemKey},
+								}
+							})
+						}
 					}
 				}
 			}
@@ -1006,7 +1028,7 @@ func (r *RootAssertionNode) ProcessEntry() {
 	}
 
 	// filter triggers for error return handling -- intra-procedural
-	if util.FuncIsErrReturning(r.FuncObj()) {
+	if r.funcIsErrReturning(r.FuncObj()) {
 		r.triggers, _ = FilterTriggersForErrorReturn(
 			r.triggers,
 			func(p *annotation.ProduceTrigger) ProducerNilability {
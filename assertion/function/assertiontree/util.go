@@ -72,7 +72,7 @@ func deepNilabilityTriggerOf(node AssertionNode) annotation.ProducingAnnotationT
 	case *fldAssertionNode:
 		return annotation.DeepNilabilityOfFld(node.decl)
 	case *indexAssertionNode:
-		return annotation.DeepNilabilityAsNamedType(node.valType)
+		return annotation.ElemProducer(deepNilabilityTriggerOf(node.Parent()), node.valType)
 	case *RootAssertionNode:
 		panic("deepNilabilityTriggerOf should NOT be called not the root node - as this would" +
 			" imply an indexNode is a child of the root node")
@@ -37,12 +37,14 @@ import (
 const _doc = "Read the contracts of each function in this package, returning the results."
 
 // Analyzer here is the analyzer than reads function contracts. It returns the map generated from
-// reading the function contracts in the source code.
+// reading the function contracts in the source code, along with the contracts of the upstream
+// functions imported via facts.
 var Analyzer = &analysis.Analyzer{
 	Name:       "nilaway_function_contracts_analyzer",
 	Doc:        _doc,
 	Run:        analysishelper.WrapRun(run),
 	ResultType: reflect.TypeOf((*analysishelper.Result[Map])(nil)),
+	FactTypes:  []analysis.Fact{new(ContractsFact)},
 	Requires:   []*analysis.Analyzer{config.Analyzer, buildssa.Analyzer},
 }
 
@@ -57,6 +59,23 @@ func run(pass *analysis.Pass) (Map, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// Export the contracts of the functions that can be called from downstream packages, and
+	// import the contracts of the upstream functions that can be called from this package.
+	for funcObj, ctrts := range contracts {
+		if funcObj.Exported() {
+			pass.ExportObjectFact(funcObj, &ContractsFact{Contracts: ctrts})
+		}
+	}
+	for _, f := range pass.AllObjectFacts() {
+		fact, ok := f.Fact.(*ContractsFact)
+		if !ok {
+			continue
+		}
+		if funcObj, ok := f.Object.(*types.Func); ok && funcObj.Pkg() != pass.Pkg {
+			contracts[funcObj] = fact.Contracts
+		}
+	}
 	return contracts, nil
 }
 
@@ -72,20 +91,8 @@ type functionResult struct {
 // the comments at the top of each function. Only when there are no handwritten contracts there,
 // do we try to automatically infer contracts.
 func collectFunctionContracts(pass *analysis.Pass) (Map, error) {
-	// Collect ssa for every function.
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
-	ssaInput := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
-	ssaOfFunc := make(map[*types.Func]*ssa.Function, len(ssaInput.SrcFuncs))
-	for _, fnssa := range ssaInput.SrcFuncs {
-		if fnssa == nil {
-			// should be guaranteed to be non-nil; otherwise it would have paniced in the library
-			// https://cs.opensource.google/go/x/tools/+/refs/tags/v0.12.0:go/analysis/passes/buildssa/buildssa.go;l=99
-			continue
-		}
-		if funcObj, ok := fnssa.Object().(*types.Func); ok {
-			ssaOfFunc[funcObj] = fnssa
-		}
-	}
+	ssaOfFunc := ssaFuncs(pass)
 
 	// Set up variables for synchronization and communication.
 	var wg sync.WaitGroup
@@ -107,30 +114,25 @@ func collectFunctionContracts(pass *analysis.Pass) (Map, error) {
 			funcObj := pass.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
 
 			// First, we try to parse the contracts from the comments at the top of the function.
-			// If there are any, we do not need to infer contracts for this function.
-			if parsedContracts := parseContracts(funcDecl.Doc); len(parsedContracts) != 0 {
-				m[funcObj] = parsedContracts
+			// If there are any valid ones, we do not need to infer contracts for this function.
+			// The invalid ones are reported by CheckAnalyzer.
+			if decls, _ := readContracts(funcDecl, funcObj.Type().(*types.Signature)); len(decls) != 0 {
+				for _, decl := range decls {
+					m[funcObj] = append(m[funcObj], decl.FunctionContract)
+				}
 				continue
 			}
 
 			// If we reach here, it means that there are no handwritten contracts for this
 			// function. We need to infer contracts for this function.
-			if funcDecl.Type.Params.NumFields() != 1 ||
-				funcDecl.Type.Results.NumFields() != 1 ||
-				util.TypeBarsNilness(funcObj.Type().(*types.Signature).Params().At(0).Type()) ||
-				util.TypeBarsNilness(funcObj.Type().(*types.Signature).Results().At(0).Type()) ||
-				funcObj.Type().(*types.Signature).Variadic() {
-				// We definitely want to ignore any function without any parameters or return
-				// values since they cannot have any contracts.
-
-				// TODO: However, we want to analyze for multiple param/return in the future; for
-				//  now we consider contract(nonnil->nonnil) only.
-
-				// TODO: If the function has only one parameter and the parameter is variadic, then
-				//  it may happen that no argument is passed when calling the function. Such cases
-				//  are not handled well when duplicating full triggers from contracted functions,
-				//  so we don't infer contract(nonnil->nonnil) for such a function although we can
-				//  already.
+			if !isContractCandidate(funcObj.Type().(*types.Signature), conf.ExperimentalMultiContractInferenceEnable) {
+				// We definitely want to ignore any function without any nilable parameters or
+				// nilable (or boolean) results since they cannot have any contracts.
+
+				// TODO: If the function has a variadic parameter, then it may happen that no
+				//  argument is passed for it when calling the function. Such cases are not handled
+				//  well when duplicating full triggers from contracted functions, so we don't
+				//  infer contracts for such a function although we can already.
 				continue
 			}
 			fnssa, ok := ssaOfFunc[funcObj]
@@ -185,3 +187,47 @@ func collectFunctionContracts(pass *analysis.Pass) (Map, error) {
 
 	return m, err
 }
+
+// ssaFuncs collects the ssa for every function declared in the package.
+func ssaFuncs(pass *analysis.Pass) map[*types.Func]*ssa.Function {
+	ssaInput := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+	ssaOfFunc := make(map[*types.Func]*ssa.Function, len(ssaInput.SrcFuncs))
+	for _, fnssa := range ssaInput.SrcFuncs {
+		if fnssa == nil {
+			// should be guaranteed to be non-nil; otherwise it would have paniced in the library
+			// https://cs.opensource.google/go/x/tools/+/refs/tags/v0.12.0:go/analysis/passes/buildssa/buildssa.go;l=99
+			continue
+		}
+		if funcObj, ok := fnssa.Object().(*types.Func); ok {
+			ssaOfFunc[funcObj] = fnssa
+		}
+	}
+	return ssaOfFunc
+}
+
+// isContractCandidate returns if contracts can be inferred for a function of the signature, i.e.,
+// it is not variadic, and it has a parameter that can be nil and a result that can be nil or is
+// a boolean. Unless multi is set (i.e., the experimental contract inference for multiple
+// parameters or results is enabled), the function must have exactly one parameter and one result
+// that can be nil, since the wider inference runs a more expensive dataflow analysis.
+func isContractCandidate(sig *types.Signature, multi bool) bool {
+	if sig.Variadic() {
+		return false
+	}
+	if !multi {
+		return sig.Params().Len() == 1 && sig.Results().Len() == 1 &&
+			!util.TypeBarsNilness(sig.Params().At(0).Type()) && !util.TypeBarsNilness(sig.Results().At(0).Type())
+	}
+	hasNilableParam := false
+	for i := 0; i < sig.Params().Len(); i++ {
+		hasNilableParam = hasNilableParam || !util.TypeBarsNilness(sig.Params().At(i).Type())
+	}
+	hasContractResult := false
+	for i := 0; i < sig.Results().Len(); i++ {
+		t := sig.Results().At(i).Type()
+		basic, isBasic := t.Underlying().(*types.Basic)
+		hasContractResult = hasContractResult || !util.TypeBarsNilness(t) ||
+			(isBasic && basic.Info()&types.IsBoolean != 0)
+	}
+	return hasNilableParam && hasContractResult
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/util/analysishelper"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
@@ -75,6 +76,24 @@ func TestContractCollection(t *testing.T) {
 			&FunctionContract{Ins: []ContractVal{Any, NonNil}, Outs: []ContractVal{NonNil, True}},
 			&FunctionContract{Ins: []ContractVal{NonNil, Any}, Outs: []ContractVal{NonNil, True}},
 		},
+		getFuncObj(pass, "partiallyInvalid"): {
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
+		},
+		getFuncObj(pass, "Exported"): {
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
+		},
+		getFuncObj(pass, "nilToNil"): {
+			&FunctionContract{Ins: []ContractVal{Nil}, Outs: []ContractVal{Nil}},
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
+		},
+		getFuncObj(pass, "disjunctive"): {
+			&FunctionContract{
+				Ins:  []ContractVal{Any},
+				Outs: []ContractVal{NonNil, Nil},
+				Or:   []*FunctionContract{{Ins: []ContractVal{Any}, Outs: []ContractVal{Nil, NonNil}}},
+			},
+		},
+		// function malformedDisjunct should not exist in the map as it has no valid contract.
 		// function contractCommentInOtherLine should not exist in the map as it has no contract.
 	}
 	if diff := cmp.Diff(expectedNameToContracts, actualNameToContracts); diff != "" {
@@ -84,6 +103,53 @@ func TestContractCollection(t *testing.T) {
 func TestInfer(t *testing.T) {
 	t.Parallel()
 
+	pass, actualNameToContracts := runInfer(t)
+	expectedNameToContracts := expectedInferredContracts(pass)
+	if diff := cmp.Diff(expectedNameToContracts, actualNameToContracts); diff != "" {
+		require.Fail(t, fmt.Sprintf("inferred contracts mismatch (-want +got):\n%s", diff))
+	}
+}
+
+func TestInfer_MultiContractInference(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel since we need to enable the
+	// experimental contract inference for multiple parameters or results to test this feature.
+	err := config.Analyzer.Flags.Set(config.ExperimentalMultiContractInferenceFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.ExperimentalMultiContractInferenceFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	pass, actualNameToContracts := runInfer(t)
+	expectedNameToContracts := expectedInferredContracts(pass)
+	for funcObj, contracts := range map[*types.Func][]*FunctionContract{
+		getFuncObj(pass, "lookup"): {
+			&FunctionContract{Ins: []ContractVal{Any, NonNil}, Outs: []ContractVal{NonNil, Any}},
+		},
+		getFuncObj(pass, "twoParams"): {
+			&FunctionContract{Ins: []ContractVal{NonNil, Any}, Outs: []ContractVal{NonNil, Any}},
+			&FunctionContract{Ins: []ContractVal{Any, NonNil}, Outs: []ContractVal{Any, NonNil}},
+		},
+		getFuncObj(pass, "isPresent"): {
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{True}},
+		},
+		getFuncObj(pass, "isAbsent"): {
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{False}},
+		},
+		getFuncObj(pass, "get"): {
+			&FunctionContract{Ins: []ContractVal{Any, NonNil}, Outs: []ContractVal{NonNil, True}},
+		},
+	} {
+		expectedNameToContracts[funcObj] = contracts
+	}
+	if diff := cmp.Diff(expectedNameToContracts, actualNameToContracts); diff != "" {
+		require.Fail(t, fmt.Sprintf("inferred contracts mismatch (-want +got):\n%s", diff))
+	}
+}
+
+// runInfer runs the analyzer on the testdata for contract inference and returns the pass and the
+// inferred contracts.
+func runInfer(t *testing.T) (*analysis.Pass, map[*types.Func][]*FunctionContract) {
 	testdata := analysistest.TestData()
 	r := analysistest.Run(t, testdata, Analyzer, "go.uber.org/functioncontracts/infer")
 
@@ -102,7 +168,13 @@ func TestInfer(t *testing.T) {
 		actualNameToContracts[funcObj] = contracts
 	}
 
-	expectedNameToContracts := map[*types.Func][]*FunctionContract{
+	return pass, actualNameToContracts
+}
+
+// expectedInferredContracts returns the contracts expected to be inferred for the functions with
+// a single parameter and a single result, which are inferred by default.
+func expectedInferredContracts(pass *analysis.Pass) map[*types.Func][]*FunctionContract {
+	return map[*types.Func][]*FunctionContract{
 		getFuncObj(pass, "onlyLocalVar"): {
 			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
 		},
@@ -121,6 +193,9 @@ func TestInfer(t *testing.T) {
 		getFuncObj(pass, "unknownToUnknownButSameValue"): {
 			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
 		},
+		getMethodObj(pass, "R", "method"): {
+			&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
+		},
 		// other functions should not exist in the map as the contract nonnil->nonnil does not hold
 		// for them.
 
@@ -133,15 +208,18 @@ func TestInfer(t *testing.T) {
 		//	&FunctionContract{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
 		// },
 	}
-	if diff := cmp.Diff(expectedNameToContracts, actualNameToContracts); diff != "" {
-		require.Fail(t, fmt.Sprintf("inferred contracts mismatch (-want +got):\n%s", diff))
-	}
 }
 
 func getFuncObj(pass *analysis.Pass, name string) *types.Func {
 	return pass.Pkg.Scope().Lookup(name).(*types.Func)
 }
 
+func getMethodObj(pass *analysis.Pass, typeName string, name string) *types.Func {
+	recv := types.NewPointer(pass.Pkg.Scope().Lookup(typeName).Type())
+	obj, _, _ := types.LookupFieldOrMethod(recv, false /* addressable */, pass.Pkg, name)
+	return obj.(*types.Func)
+}
+
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
@@ -0,0 +1,246 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functioncontracts
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/util/analysishelper"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+const _checkDoc = "Verify the handwritten contracts of each function in this package against the" +
+	" function signature and body, reporting the contracts that are malformed or can be violated"
+
+// CheckAnalyzer is the analyzer that verifies the handwritten function contracts, which are
+// otherwise trusted by Analyzer as is (or dropped if invalid). First, it reports the contracts that
+// are malformed or invalid for the signature of the function (see readContracts). Then, it runs
+// the same nilness dataflow analysis as the contract inference over the body of the function, and
+// reports the valid contracts violated by a return path of the function, i.e., a path where the
+// parameters required to be nonnil by the contract are not nil, but a result guaranteed by the
+// contract is nil (or the opposite boolean). Since the analysis cannot prove every contract (which
+// is why the contracts are handwritten in the first place), only such definite violations are
+// reported.
+var CheckAnalyzer = &analysis.Analyzer{
+	Name:       "nilaway_function_contracts_check_analyzer",
+	Doc:        _checkDoc,
+	Run:        analysishelper.WrapRun(check),
+	ResultType: reflect.TypeOf((*analysishelper.Result[[]analysis.Diagnostic])(nil)),
+	Requires:   []*analysis.Analyzer{config.Analyzer, buildssa.Analyzer},
+}
+
+func check(pass *analysis.Pass) ([]analysis.Diagnostic, error) {
+	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+	if !conf.IsPkgInScope(pass.Pkg) {
+		return nil, nil
+	}
+
+	ssaOfFunc := ssaFuncs(pass)
+	var diagnostics []analysis.Diagnostic
+	for _, file := range pass.Files {
+		if !conf.IsFileInScope(file) {
+			continue
+		}
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Doc == nil {
+				continue
+			}
+			funcObj := pass.TypesInfo.ObjectOf(funcDecl.Name).(*types.Func)
+			// Report the malformed or invalid contracts, and verify the valid ones.
+			contracts, parseDiagnostics := readContracts(funcDecl, funcObj.Type().(*types.Signature))
+			diagnostics = append(diagnostics, parseDiagnostics...)
+			fnssa, ok := ssaOfFunc[funcObj]
+			if len(contracts) == 0 || !ok || len(fnssa.Blocks) == 0 {
+				continue
+			}
+			diagnostics = append(diagnostics, checkFunc(pass, funcDecl, fnssa, contracts)...)
+		}
+	}
+	return diagnostics, nil
+}
+
+// checkFunc verifies the handwritten contracts of the function against its body, and returns the
+// diagnostics for the violated ones.
+func checkFunc(
+	pass *analysis.Pass,
+	funcDecl *ast.FuncDecl,
+	fn *ssa.Function,
+	contracts []*contractDecl,
+) []analysis.Diagnostic {
+	tables, ok := computeNilnessTables(fn)
+	if !ok {
+		// Too many tables, we cannot verify the contracts of this function.
+		return nil
+	}
+	var diagnostics []analysis.Diagnostic
+	for _, ctr := range contracts {
+		if d, ok := checkContract(pass, funcDecl, fn, ctr.FunctionContract, tables); ok {
+			d.Related = append([]analysis.RelatedInformation{{
+				Pos: ctr.pos, End: ctr.end, Message: "contract declared here",
+			}}, d.Related...)
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics
+}
+
+// checkContract looks for a return path of the function violating the contract, and returns the
+// diagnostic for the first one found (if any), which shows the conditions taken on the path. A
+// disjunctive contract is violated on the path only if all its disjuncts are violated.
+func checkContract(
+	pass *analysis.Pass,
+	funcDecl *ast.FuncDecl,
+	fn *ssa.Function,
+	ctr *FunctionContract,
+	nilnessTableSetByBB map[*ssa.BasicBlock]nilnessTableSet,
+) (analysis.Diagnostic, bool) {
+	params := contractParams(fn)
+	for _, retInstr := range getReturnInstrs(fn) {
+		tables := newNilnessTableSet()
+		if r, ok := nilnessTableSetByBB[retInstr.Block()]; ok && len(r) != 0 {
+			tables = r
+		} else {
+			tables, _ = add(tables, nilnessTable{})
+		}
+	nextTable:
+		for _, table := range tables {
+			var violations []string
+			for _, d := range ctr.Disjuncts() {
+				v, ok := table.violationOf(d, params, retInstr)
+				if !ok {
+					continue nextTable
+				}
+				if !slices.Contains(violations, v) {
+					violations = append(violations, v)
+				}
+			}
+			pos := retInstr.Pos()
+			if !pos.IsValid() {
+				// implicit return at the end of the function
+				pos = funcDecl.Body.Rbrace
+			}
+			conds, related := pathConditions(retInstr.Block(), funcDecl)
+			msg := fmt.Sprintf("function `%s()` violates its contract `%s` by returning %s",
+				funcDecl.Name.Name, ctr, strings.Join(violations, " and "))
+			if len(conds) != 0 {
+				msg += " when " + strings.Join(conds, " and ")
+			}
+			return analysis.Diagnostic{Pos: pos, Message: msg, Related: related}, true
+		}
+	}
+	return analysis.Diagnostic{}, false
+}
+
+// violationOf returns the description of the first result (e.g., "nil in position 0") that
+// definitely violates the contract (without disjunction) at the return instruction, and false if
+// the contract does not apply or no result violates it.
+func (t nilnessTable) violationOf(ctr *FunctionContract, params []*ssa.Parameter, retInstr *ssa.Return) (string, bool) {
+	if !t.satisfies(ctr.Ins, params) {
+		return "", false
+	}
+	for i, val := range ctr.Outs {
+		if returned, ok := t.violation(retInstr.Results[i], val); ok {
+			return fmt.Sprintf("%s in position %d", returned, i), true
+		}
+	}
+	return "", false
+}
+
+// satisfies returns if the nilnessTable does not contradict the contract values of the parameters,
+// i.e., the parameters required to be nonnil are not nil, and the ones required to be nil are not
+// nonnil.
+func (t nilnessTable) satisfies(ins []ContractVal, params []*ssa.Parameter) bool {
+	for i, val := range ins {
+		nilness := t.nilnessOf(params[i])
+		if (val == NonNil && nilness == isnil) || (val == Nil && nilness == isnonnil) {
+			return false
+		}
+	}
+	return true
+}
+
+// violation returns the description of the returned value (e.g., "nil") if it definitely violates
+// the contract value, and false otherwise.
+func (t nilnessTable) violation(ret ssa.Value, val ContractVal) (string, bool) {
+	switch val {
+	case NonNil:
+		if t.nilnessOf(ret) == isnil {
+			return "nil", true
+		}
+	case Nil:
+		if t.nilnessOf(ret) == isnonnil {
+			return "nonnil", true
+		}
+	case True, False:
+		if b, ok := boolConstOf(ret); ok && b != (val == True) {
+			return fmt.Sprint(b), true
+		}
+	}
+	return "", false
+}
+
+// pathConditions returns the descriptions of the branch conditions taken on every path to the
+// block (i.e., the `if` conditions whose branches dominate the block) in the order of the path,
+// along with their related information. The conditions not found in the function declaration
+// (e.g., the ones generated by the compiler) are skipped.
+func pathConditions(b *ssa.BasicBlock, funcDecl *ast.FuncDecl) ([]string, []analysis.RelatedInformation) {
+	// The binary conditions are positioned at their operators in SSA.
+	exprs := make(map[token.Pos]ast.Expr)
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		if expr, ok := node.(*ast.BinaryExpr); ok {
+			exprs[expr.OpPos] = expr
+		}
+		return true
+	})
+
+	var (
+		conds   []string
+		related []analysis.RelatedInformation
+	)
+	for child, parent := b, b.Idom(); parent != nil; child, parent = parent, parent.Idom() {
+		ifInstr, ok := parent.Instrs[len(parent.Instrs)-1].(*ssa.If)
+		if !ok {
+			continue
+		}
+		var taken bool
+		switch {
+		case parent.Succs[0].Dominates(child):
+			taken = true
+		case parent.Succs[1].Dominates(child):
+			taken = false
+		default:
+			// Both branches reach the block.
+			continue
+		}
+		expr, ok := exprs[ifInstr.Cond.Pos()]
+		if !ok {
+			continue
+		}
+		cond := fmt.Sprintf("`%s` is %t", types.ExprString(expr), taken)
+		conds = append([]string{cond}, conds...)
+		related = append([]analysis.RelatedInformation{{Pos: expr.Pos(), End: expr.End(), Message: cond}}, related...)
+	}
+	return conds, related
+}
@@ -16,6 +16,10 @@ package functioncontracts
 
 import (
 	"go/types"
+	"slices"
+	"strings"
+
+	"go.uber.org/nilaway/util"
 )
 
 // ContractVal represents the possible value appearing in a function contract.
@@ -24,6 +28,8 @@ type ContractVal string
 const (
 	// NonNil has keyword "nonnil".
 	NonNil ContractVal = "nonnil"
+	// Nil has keyword "nil".
+	Nil ContractVal = "nil"
 	// False has keyword "false".
 	False ContractVal = "false"
 	// True has keyword "true".
@@ -32,30 +38,232 @@ const (
 	Any ContractVal = "_"
 )
 
-// stringToContractVal converts a keyword string into the corresponding function ContractVal.
-func stringToContractVal(keyword string) ContractVal {
+// stringToContractVal converts a keyword string into the corresponding function ContractVal, and
+// returns false if the keyword is unknown.
+func stringToContractVal(keyword string) (ContractVal, bool) {
 	switch keyword {
 	case "nonnil":
-		return NonNil
+		return NonNil, true
+	case "nil":
+		return Nil, true
 	case "false":
-		return False
+		return False, true
 	case "true":
-		return True
+		return True, true
 	case "_":
-		return Any
+		return Any, true
 	default:
-		// TODO: The ideal way to handle this is to keep track of this contract parsing error and
-		//  move on to the other contracts. But this may also require some refactoring of other
-		//  parts (we do not currently handle partial recoveries anyways)
-		panic("Unexpected keyword for ContractVal: " + keyword)
+		return "", false
 	}
 }
 
-// FunctionContract represents a function contract.
+// FunctionContract represents a function contract `contract(Ins -> Outs)`, which guarantees the
+// values of the results (Outs) if the arguments match the values of the parameters (Ins). It can
+// also be a disjunctive contract, e.g., `contract(_ -> nonnil, nil) | contract(_ -> nil, nonnil)`,
+// which holds if any of its disjuncts holds. In such a case, Ins and Outs store the first disjunct
+// and Or stores the others.
 type FunctionContract struct {
 	Ins  []ContractVal
 	Outs []ContractVal
+	// Or stores the other disjuncts of a disjunctive contract, which is nil for a contract without
+	// disjunction.
+	Or []*FunctionContract
+}
+
+// String returns the contract in the form of its keyword, e.g., `contract(_, nonnil -> nonnil)`.
+func (c *FunctionContract) String() string {
+	join := func(vals []ContractVal) string {
+		strs := make([]string, len(vals))
+		for i, v := range vals {
+			strs[i] = string(v)
+		}
+		return strings.Join(strs, _sep+" ")
+	}
+	str := _contractKeyword + "(" + join(c.Ins) + " " + _arrow + " " + join(c.Outs) + ")"
+	for _, d := range c.Or {
+		str += " " + _or + " " + d.String()
+	}
+	return str
+}
+
+// Disjuncts returns the disjuncts of the contract, i.e., the first one stored in Ins and Outs
+// followed by the others in Or. A contract without disjunction is its only disjunct.
+func (c *FunctionContract) Disjuncts() []*FunctionContract {
+	return append([]*FunctionContract{{Ins: c.Ins, Outs: c.Outs}}, c.Or...)
+}
+
+// NonNilParam returns the index of the only parameter required to be nonnil by the contract (i.e.,
+// all other parameters are `_`), and false if there is no such parameter or the contract is
+// disjunctive.
+func (c *FunctionContract) NonNilParam() (int, bool) {
+	return c.singleParam(NonNil)
+}
+
+// NilParam returns the index of the only parameter required to be nil by the contract (i.e., all
+// other parameters are `_`), and false if there is no such parameter or the contract is
+// disjunctive.
+func (c *FunctionContract) NilParam() (int, bool) {
+	return c.singleParam(Nil)
+}
+
+func (c *FunctionContract) singleParam(val ContractVal) (int, bool) {
+	if len(c.Or) != 0 {
+		return 0, false
+	}
+	index := -1
+	for i, v := range c.Ins {
+		switch {
+		case v == Any:
+		case v == val && index == -1:
+			index = i
+		default:
+			return 0, false
+		}
+	}
+	return index, index != -1
+}
+
+// NonNilResults returns whether each result is guaranteed to be nonnil by the contract.
+func (c *FunctionContract) NonNilResults() []bool {
+	return c.resultsOf(NonNil)
+}
+
+// NilResults returns whether each result is guaranteed to be nil by the contract.
+func (c *FunctionContract) NilResults() []bool {
+	return c.resultsOf(Nil)
+}
+
+func (c *FunctionContract) resultsOf(val ContractVal) []bool {
+	results := make([]bool, len(c.Outs))
+	for i, v := range c.Outs {
+		results[i] = v == val && len(c.Or) == 0
+	}
+	return results
 }
 
 // Map stores the mappings from *types.Func to associated function contracts.
 type Map map[*types.Func][]*FunctionContract
+
+// Duplicable returns the contracts of the function if its full triggers can be duplicated to the
+// call sites for context sensitivity, i.e., it is not an error-returning function whose results
+// are handled separately, and its contracts are either
+//   - a single contract that requires one parameter to be nonnil and guarantees some results to
+//     be nonnil (e.g., `contract(_, nonnil -> nonnil, _)`), whose argument controls the duplicated
+//     full triggers returning such results (see ControllingParam), or
+//   - contracts that require one parameter to be nil and guarantee some results to be nil (e.g.,
+//     `contract(nil -> nil)`), whose arguments flow to such results at the call sites.
+//
+// The boolean results of the contracts are not used in the duplication.
+func (m Map) Duplicable(funcObj *types.Func) ([]*FunctionContract, bool) {
+	contracts, ok := m[funcObj]
+	if !ok || len(contracts) == 0 || util.FuncIsErrReturning(funcObj) {
+		return nil, false
+	}
+	sig := funcObj.Type().(*types.Signature)
+	if sig.Variadic() {
+		return nil, false
+	}
+	numNonNil := 0
+	for _, ctr := range contracts {
+		if len(ctr.Ins) != sig.Params().Len() || len(ctr.Outs) != sig.Results().Len() {
+			return nil, false
+		}
+		_, isNonNil := ctr.NonNilParam()
+		_, isNil := ctr.NilParam()
+		switch {
+		case isNonNil && slices.Contains(ctr.NonNilResults(), true):
+			numNonNil++
+		case isNil && slices.Contains(ctr.NilResults(), true):
+		default:
+			return nil, false
+		}
+	}
+	if numNonNil > 1 {
+		return nil, false
+	}
+	return contracts, true
+}
+
+// ControllingParam returns the parameter whose argument controls the result at the call sites of
+// a duplicable function (see Map.Duplicable), i.e., the parameter required to be nonnil by the
+// contract guaranteeing the result to be nonnil, and false if the result is not guaranteed so.
+func ControllingParam(contracts []*FunctionContract, retNum int) (int, bool) {
+	for _, ctr := range contracts {
+		if param, ok := ctr.NonNilParam(); ok && ctr.NonNilResults()[retNum] {
+			return param, true
+		}
+	}
+	return 0, false
+}
+
+// IsErrReturning returns if the function is deemed "error-returning" by its contracts, which is
+// otherwise decided by its signature (see util.FuncIsErrReturning). That is, it has a contract
+// guarding its results by the last one as the error, e.g., `contract(_ -> nonnil, nil) |
+// contract(_ -> nil, nonnil)` for a function returning `(*T, *MyError)` (see hasGuardContract).
+func (m Map) IsErrReturning(funcObj *types.Func) bool {
+	return m.hasGuardContract(funcObj, Nil, NonNil)
+}
+
+// IsOkReturning returns if the function is deemed "ok-returning" by its contracts, which is
+// otherwise decided by its signature (see util.FuncIsOkReturning). That is, it has a contract
+// guarding its results by the last one as the boolean, e.g., `contract(_ -> nonnil, true) |
+// contract(_ -> nil, false)` that expresses "result 0 is nonnil iff result 1 is true" (see
+// hasGuardContract).
+func (m Map) IsOkReturning(funcObj *types.Func) bool {
+	return m.hasGuardContract(funcObj, True, False)
+}
+
+// hasGuardContract returns if the function has a disjunctive contract of two disjuncts regardless
+// of the arguments (i.e., all parameters are `_`), where the last result is the guard: one
+// disjunct guarantees the guard value and all other nilable results to be nonnil, and the other
+// disjunct guarantees the opposite guard value.
+func (m Map) hasGuardContract(funcObj *types.Func, guard ContractVal, opposite ContractVal) bool {
+	results := funcObj.Type().(*types.Signature).Results()
+	n := results.Len()
+	if n < 2 {
+		return false
+	}
+	isGuarded := func(guarded, other *FunctionContract) bool {
+		if len(guarded.Outs) != n || len(other.Outs) != n ||
+			guarded.Outs[n-1] != guard || other.Outs[n-1] != opposite {
+			return false
+		}
+		for i := 0; i < n-1; i++ {
+			if guarded.Outs[i] != NonNil && !util.TypeBarsNilness(results.At(i).Type()) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, ctr := range m[funcObj] {
+		disjuncts := ctr.Disjuncts()
+		if len(disjuncts) != 2 || slices.ContainsFunc(disjuncts, func(d *FunctionContract) bool {
+			return slices.ContainsFunc(d.Ins, func(v ContractVal) bool { return v != Any })
+		}) {
+			continue
+		}
+		if isGuarded(disjuncts[0], disjuncts[1]) || isGuarded(disjuncts[1], disjuncts[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContractsFact is the fact exported for every contracted function that can be called from
+// downstream packages, such that the contracts (handwritten or inferred) can be instantiated at the
+// call sites of the function in the downstream packages.
+type ContractsFact struct {
+	Contracts []*FunctionContract
+}
+
+// AFact enables use of the facts passing mechanism in Go's analysis framework.
+func (*ContractsFact) AFact() {}
+
+// String returns the contracts of the fact separated by spaces.
+func (f *ContractsFact) String() string {
+	strs := make([]string, len(f.Contracts))
+	for i, c := range f.Contracts {
+		strs[i] = c.String()
+	}
+	return strings.Join(strs, " ")
+}
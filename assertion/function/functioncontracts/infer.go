@@ -15,6 +15,7 @@
 package functioncontracts
 
 import (
+	"go/constant"
 	"go/token"
 	"go/types"
 
@@ -27,17 +28,39 @@ import (
 // infer nothing, out of performance consideration.
 const _maxNumTablesPerBlock = 1024
 
+// _maxNumTablesPerFunction is the maximum number of nilnessTables that we will keep for all blocks
+// of a function, which grows with the number of the parameters compared against nil. Same as
+// _maxNumTablesPerBlock, we infer nothing for the function if the number is exceeded.
+const _maxNumTablesPerFunction = 8 * _maxNumTablesPerBlock
+
 // inferContracts infers function contracts for a function if it has no contracts written. It
 // returns a list of inferred contracts, which may be empty if no contract is inferred but is never
 // nil.
 func inferContracts(fn *ssa.Function) []*FunctionContract {
-	nilnessTableSetByBB := make(map[*ssa.BasicBlock]nilnessTableSet)
 	retInstrs := getReturnInstrs(fn) // TODO: Consider *ssa.Panic
 	// No need of an expensive dataflow analysis if we can derive contracts from the return
-	// instructions directly.
-	if ctrs := deriveContracts(retInstrs, fn, nilnessTableSetByBB); len(ctrs) != 0 {
-		return ctrs
+	// instructions directly. This is only done for the functions with a single parameter and a
+	// single result, since the contracts for other parameters or results may still be missed.
+	if len(contractParams(fn)) == 1 && fn.Signature.Results().Len() == 1 {
+		noTables := make(map[*ssa.BasicBlock]nilnessTableSet)
+		if ctrs := deriveContracts(retInstrs, fn, noTables); len(ctrs) != 0 {
+			return ctrs
+		}
+	}
+	nilnessTableSetByBB, ok := computeNilnessTables(fn)
+	if !ok {
+		// Too many tables, we should give up inferring contracts for this function.
+		return []*FunctionContract{}
 	}
+	return deriveContracts(retInstrs, fn, nilnessTableSetByBB)
+}
+
+// computeNilnessTables runs the dataflow analysis over the blocks of the function, and returns the
+// nilnessTables reaching every block, i.e., the possible nilness of the values along the paths to
+// the block. It returns false if the analysis is given up due to too many tables.
+func computeNilnessTables(fn *ssa.Function) (map[*ssa.BasicBlock]nilnessTableSet, bool) {
+	nilnessTableSetByBB := make(map[*ssa.BasicBlock]nilnessTableSet)
+	numTables := 0
 
 	// Add the entry block to the queue.
 	// TODO: visit fn.Recover.
@@ -138,16 +161,16 @@ func inferContracts(fn *ssa.Function) []*FunctionContract {
 		seen[b.Index] = true
 
 		// TODO: nicely handle exponential explosion of tables.
-		if len(nilnessTableSetByBB[b]) >= _maxNumTablesPerBlock {
-			// Too many tables, we should give up inferring contracts for this function.
-			return []*FunctionContract{}
+		numTables += len(nilnessTableSetByBB[b])
+		if len(nilnessTableSetByBB[b]) >= _maxNumTablesPerBlock || numTables >= _maxNumTablesPerFunction {
+			return nil, false
 		}
 
 		// Add successors to queue since the nilness table set of this block has been updated.
 		queue = append(queue, b.Succs...)
 	}
 
-	return deriveContracts(retInstrs, fn, nilnessTableSetByBB)
+	return nilnessTableSetByBB, true
 }
 
 // learnNilness learns nilness for the block succ, extended from one nilnessTable table of its
@@ -200,25 +223,69 @@ func learnNilness(succ *ssa.BasicBlock, pred *ssa.BasicBlock, table nilnessTable
 }
 
 // deriveContracts checks nilness of parameter and return values at every exit block to infer
-// contracts.
+// contracts. For every parameter that can be nil, it infers a contract that requires the parameter
+// to be nonnil (and leaves other parameters `_`), if the contract guarantees any result to be
+// nonnil, true or false (see deriveContractVal).
 func deriveContracts(
 	retInstrs []*ssa.Return,
 	fn *ssa.Function,
 	nilnessTableSetByBB map[*ssa.BasicBlock]nilnessTableSet) []*FunctionContract {
-	// TODO: verify other or multiple param/return contracts in the future; for now we consider
-	//  contract(nonnil->nonnil) only.
-	param := fn.Params[0]
+	params := contractParams(fn)
+	results := fn.Signature.Results()
+
+	contracts := make([]*FunctionContract, 0)
+	for i, param := range params {
+		if util.TypeBarsNilness(param.Type()) {
+			continue
+		}
+		ctr := &FunctionContract{Ins: make([]ContractVal, len(params)), Outs: make([]ContractVal, results.Len())}
+		for j := range ctr.Ins {
+			ctr.Ins[j] = Any
+		}
+		ctr.Ins[i] = NonNil
+		isUseful := false
+		for j := range ctr.Outs {
+			ctr.Outs[j] = deriveContractVal(retInstrs, param, j, results.At(j).Type(), nilnessTableSetByBB)
+			isUseful = isUseful || ctr.Outs[j] != Any
+		}
+		if isUseful {
+			contracts = append(contracts, ctr)
+		}
+	}
+	return contracts
+}
+
+// deriveContractVal derives the value of the result at index retIndex guaranteed by a nonnil
+// param, i.e., NonNil for a result that can be nil, True or False for a boolean result, or Any if
+// nothing is guaranteed.
+func deriveContractVal(
+	retInstrs []*ssa.Return,
+	param *ssa.Parameter,
+	retIndex int,
+	retType types.Type,
+	nilnessTableSetByBB map[*ssa.BasicBlock]nilnessTableSet) ContractVal {
+	isBool := false
+	if basic, ok := retType.Underlying().(*types.Basic); ok {
+		isBool = basic.Info()&types.IsBoolean != 0
+	}
+	if !isBool && util.TypeBarsNilness(retType) {
+		return Any
+	}
+
 	nonnilOrUnknownParamChoices := 0
 	nilParamChoices := 0
 	nonnilRetChoices := 0
 	totalChoices := 0
+	// boolRetChoices counts the choices returning true (index 1) or false (index 0).
+	var boolRetChoices, boolRetChoicesUnderParam [2]int
+	isNonNil := !isBool
 
-	// We try to find a counterexample to nonnil->nonnil. If we find one, we immediately break out
-	// of the loop and return the contract(nonnil->nonnil) does not hold. Otherwise, we will
-	// move on to post-check before we can conclude the contaract(nonnil->nonnil) holds.
+	// We try to find a counterexample to nonnil->nonnil (or nonnil->true/false). If we find one,
+	// we no longer consider the corresponding value. Otherwise, we will move on to post-check
+	// before we can conclude the contract holds.
 	for _, retInstr := range retInstrs {
 		// b ends with a return
-		ret := retInstr.Results[0]
+		ret := retInstr.Results[retIndex]
 		tables := newNilnessTableSet()
 		if r, ok := nilnessTableSetByBB[retInstr.Block()]; ok {
 			tables = r
@@ -228,6 +295,22 @@ func deriveContracts(
 		for _, table := range tables {
 			totalChoices++
 			pNil := table.nilnessOf(param)
+			b, isConst := boolConstOf(ret)
+			if isConst {
+				boolRetChoices[boolIndex(b)]++
+			}
+			if isBool {
+				if pNil == isnil {
+					nilParamChoices++
+					continue
+				}
+				nonnilOrUnknownParamChoices++
+				if isConst {
+					boolRetChoicesUnderParam[boolIndex(b)]++
+				}
+				continue
+			}
+
 			rNil := table.nilnessOf(ret)
 			// All the possibilities:
 			// nonnil->nonnil     // OK
@@ -253,37 +336,60 @@ func deriveContracts(
 			// pNil == isnonnil or unknown, rNil can be anything, i.e. isnonnil, unknown, isnil.
 			nonnilOrUnknownParamChoices++
 			if rNil == isnonnil || // Absolutely OK if rNil == isnonnil
-				(pNil == unknown && rNil == unknown && param == ret) { // The only OK case otherwise
+				(pNil == unknown && rNil == unknown && ssa.Value(param) == ret) { // The only OK case otherwise
 				// Those cases are not counterexamples to contract(nonnil->nonnil)
 				continue
 			}
 			// All the remaining cases are counterexamples to contract(nonnil->nonnil)
-			return []*FunctionContract{}
+			isNonNil = false
 		}
 	}
 
-	// Post-check: we deny contract(nonnil->nonnil) for the following cases:
+	// Post-check: we deny the contract for the following cases:
 	//
-	// 1. If the parameter is nil at every path, the contract nonnil->nonnil is trivially true, but
-	// we suppress inferring such a useless contract to avoid the following overhead, such as
-	// trigger duplication. However, I feel it is not possible that all paths have the parameter as
-	// isnil, since the parameter always starts with unknown, and we do only branching nil and
-	// nonnil.
+	// 1. If the parameter is nil at every path, the contract is trivially true, but we suppress
+	// inferring such a useless contract to avoid the following overhead, such as trigger
+	// duplication. However, I feel it is not possible that all paths have the parameter as isnil,
+	// since the parameter always starts with unknown, and we do only branching nil and nonnil.
 	//
-	// 2. It is essentially _->nonnil that holds for this function, so it is not necessary to
-	// infer nonnil->nonnil.
-	if (nilParamChoices == totalChoices && nonnilOrUnknownParamChoices == 0) ||
-		nonnilRetChoices == totalChoices {
-		return []*FunctionContract{}
+	// 2. It is essentially _->nonnil (or _->true/false) that holds for this function, so it is not
+	// necessary to infer nonnil->nonnil (or nonnil->true/false).
+	if nilParamChoices == totalChoices && nonnilOrUnknownParamChoices == 0 {
+		return Any
 	}
+	switch {
+	case isNonNil && nonnilRetChoices != totalChoices:
+		// nonnil->nonnil is valid at all exit blocks
+		return NonNil
+	case isBool && boolRetChoicesUnderParam[1] == nonnilOrUnknownParamChoices && boolRetChoices[1] != totalChoices:
+		return True
+	case isBool && boolRetChoicesUnderParam[0] == nonnilOrUnknownParamChoices && boolRetChoices[0] != totalChoices:
+		return False
+	}
+	return Any
+}
 
-	// totalChoices > nilParamChoices >= 0 && totalChoices >= nonnilOrUnknownParamChoices > 0 &&
-	// nonnilRetChoices < totalChoices
+// contractParams returns the parameters of the function that appear in its contracts, i.e.,
+// excluding the receiver of a method.
+func contractParams(fn *ssa.Function) []*ssa.Parameter {
+	return fn.Params[len(fn.Params)-fn.Signature.Params().Len():]
+}
+
+// boolConstOf returns the value of v if it is a boolean constant.
+func boolConstOf(v ssa.Value) (value bool, ok bool) {
+	c, ok := v.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Bool {
+		return false, false
+	}
+	return constant.BoolVal(c.Value), true
+}
 
-	// nonnil->nonnil is valid at all exit blocks
-	return []*FunctionContract{
-		{Ins: []ContractVal{NonNil}, Outs: []ContractVal{NonNil}},
+// boolIndex returns 1 for true and 0 for false.
+func boolIndex(b bool) int {
+	if b {
+		return 1
 	}
+	return 0
 }
 
 func getReturnInstrs(fn *ssa.Function) []*ssa.Return {
@@ -17,58 +17,262 @@ package functioncontracts
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
+	"go/types"
 	"regexp"
 	"strings"
+
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
 )
 
 const _sep = ","
+const _arrow = "->"
+const _or = "|"
 const _contractKeyword = "contract"
-const _contractValKeyword = NonNil + "|" + False + "|" + True + "|" + Any
-
-// _contractRE matches multiple function contracts in the same line. Each contract looks like
-// `contract(VALUE(,VALUE)+ -> VALUE(,VALUE)+)`. The RE also captures two lists of VALUEs,
-// i.e., the part before and after `->` for each contract.
-// Note that we match start and end of the line here and add a non-capturing group for the entire
-// contract, and we disallow anything else except whitespace to appear between contracts, so we
-// acknowledge only the contracts written in their own line.
-var _contractRE = regexp.MustCompile(
-	fmt.Sprintf("^\\s*//\\s*(?:\\s*%s\\s*\\(\\s*((?:%s)(?:\\s*,\\s*(?:%s))*)\\s*->\\s*((?:%s)(?:\\s*,\\s*(?:%s))*)\\s*\\)\\s*)+$",
-		_contractKeyword, _contractValKeyword, _contractValKeyword, _contractValKeyword, _contractValKeyword))
-
-// parseContracts parses a slice of function contracts from a singe comment group. If no contract
-// is found from the comment group, an empty slice is returned.
-func parseContracts(doc *ast.CommentGroup) []*FunctionContract {
-	contracts := make([]*FunctionContract, 0)
+
+// _contractLineRE matches the comment lines that look like function contracts, i.e., the lines
+// starting with `contract(` and ending with `)`. Such lines are read as function contracts, and
+// reported if malformed. Note that we acknowledge only the contracts written in their own lines,
+// so the contracts mentioned in the docs (e.g., "`contract(nonnil -> nonnil)` holds") are not read.
+var _contractLineRE = regexp.MustCompile(fmt.Sprintf(`^//\s*%s\s*\(.*\)\s*$`, _contractKeyword))
+
+// _contractRE matches every contract in a line, e.g., `contract(VALUE(,VALUE)* -> VALUE(,VALUE)*)`,
+// and captures the values in between the parentheses. The contracts separated by `|` in the line
+// are the disjuncts of a disjunctive contract.
+var _contractRE = regexp.MustCompile(fmt.Sprintf(`%s\s*\(([^()]*)\)`, _contractKeyword))
+
+// contractDecl is a function contract along with its position in the comment.
+type contractDecl struct {
+	*FunctionContract
+	pos, end token.Pos
+	// valPos stores the positions of the values in Ins followed by the ones in Outs.
+	valPos []token.Pos
+	// or stores the other disjuncts of a disjunctive contract, see FunctionContract.Or.
+	or []*contractDecl
+}
+
+// contractParser parses and validates the function contracts written in the doc of a function,
+// and collects the diagnostics for the malformed or invalid ones.
+type contractParser struct {
+	funcName    string
+	diagnostics []analysis.Diagnostic
+}
+
+func (p *contractParser) report(pos token.Pos, format string, args ...any) {
+	p.diagnostics = append(p.diagnostics, analysis.Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// readContracts parses the function contracts from the doc of the function, and validates them
+// against its signature. It returns the valid contracts, which may be empty but is never nil, and
+// the diagnostics for the invalid ones, which are dropped such that the analysis can continue.
+func readContracts(funcDecl *ast.FuncDecl, sig *types.Signature) ([]*contractDecl, []analysis.Diagnostic) {
+	p := &contractParser{funcName: funcDecl.Name.Name}
+	return p.validate(p.parse(funcDecl.Doc), sig), p.diagnostics
+}
+
+// parse parses the function contracts in the lines of the comment group that look like contracts.
+func (p *contractParser) parse(doc *ast.CommentGroup) []*contractDecl {
 	if doc == nil {
-		return contracts
+		return nil
 	}
-	for _, lineComment := range doc.List {
-		res := _contractRE.FindAllStringSubmatch(lineComment.Text, -1)
-		if res == nil {
+	var decls []*contractDecl
+	for _, comment := range doc.List {
+		if !_contractLineRE.MatchString(comment.Text) {
 			continue
 		}
-		for _, matching := range res {
-			// matching is a slice of three elements; the first is the whole matched string and the
-			// next two are the captured groups of contract values before and after `->`.
-			ins := parseListOfContractValues(matching[1])
-			outs := parseListOfContractValues(matching[2])
-			ctrt := &FunctionContract{
-				Ins:  ins,
-				Outs: outs,
+		text := comment.Text
+		var (
+			// disjuncts stores the disjuncts of the contract being parsed, which is dropped if any
+			// of them is malformed.
+			disjuncts []*contractDecl
+			malformed bool
+		)
+		flush := func() {
+			if len(disjuncts) != 0 && !malformed {
+				decl := disjuncts[0]
+				for _, d := range disjuncts[1:] {
+					decl.or = append(decl.or, d)
+					decl.Or = append(decl.Or, d.FunctionContract)
+				}
+				decl.end = disjuncts[len(disjuncts)-1].end
+				decls = append(decls, decl)
 			}
-			contracts = append(contracts, ctrt)
+			disjuncts, malformed = nil, false
 		}
+		// Only whitespace (or `|` for disjunctions) is allowed in between the contracts in the line.
+		last := len("//")
+		checkGap := func(end int) {
+			if gap := strings.TrimSpace(text[last:end]); gap != "" {
+				p.report(comment.Pos()+token.Pos(last+strings.Index(text[last:end], gap)),
+					"unexpected text `%s` in function contracts on function `%s`, only contracts are allowed in the line",
+					gap, p.funcName)
+			}
+		}
+		for _, match := range _contractRE.FindAllStringSubmatchIndex(text, -1) {
+			if len(disjuncts) == 0 || strings.TrimSpace(text[last:match[0]]) != _or {
+				checkGap(match[0])
+				flush()
+			}
+			last = match[1]
+			decl, ok := p.parseContract(comment, match)
+			disjuncts, malformed = append(disjuncts, decl), malformed || !ok
+		}
+		checkGap(len(text))
+		flush()
+	}
+	return decls
+}
+
+// parseContract parses a single contract in the comment, where the match stores the indices of the
+// whole contract and the captured values in the text of the comment.
+func (p *contractParser) parseContract(comment *ast.Comment, match []int) (*contractDecl, bool) {
+	decl := &contractDecl{
+		FunctionContract: &FunctionContract{},
+		pos:              comment.Pos() + token.Pos(match[0]),
+		end:              comment.Pos() + token.Pos(match[1]),
 	}
-	return contracts
+	contractStr := comment.Text[match[0]:match[1]]
+	parts := strings.Split(comment.Text[match[2]:match[3]], _arrow)
+	if len(parts) != 2 {
+		p.report(decl.pos, "malformed function contract `%s` on function `%s`, expected `%s(VALUE, ... %s VALUE, ...)`",
+			contractStr, p.funcName, _contractKeyword, _arrow)
+		return nil, false
+	}
+
+	ok := true
+	offset := match[2]
+	for i, part := range parts {
+		for _, valStr := range strings.Split(part, _sep) {
+			pos := comment.Pos() + token.Pos(offset+len(valStr)-len(strings.TrimLeft(valStr, " \t")))
+			offset += len(valStr) + len(_sep)
+			valStr = strings.TrimSpace(valStr)
+			val, isVal := stringToContractVal(valStr)
+			switch {
+			case valStr == "":
+				p.report(pos, "missing value in function contract `%s` on function `%s`", contractStr, p.funcName)
+				ok = false
+			case !isVal:
+				p.report(pos, "unknown value `%s` in function contract `%s` on function `%s`, expected one of `%s`, `%s`, `%s`, `%s` or `%s`",
+					valStr, contractStr, p.funcName, NonNil, Nil, True, False, Any)
+				ok = false
+			case i == 0:
+				decl.Ins = append(decl.Ins, val)
+			default:
+				decl.Outs = append(decl.Outs, val)
+			}
+			decl.valPos = append(decl.valPos, pos)
+		}
+		// The last separator is replaced by the arrow.
+		offset += len(_arrow) - len(_sep)
+	}
+	return decl, ok
 }
 
-// parseListOfContractValues splits a string of comma separated contract value keywords and returns
-// a slice of ContractVal.
-func parseListOfContractValues(wholeStr string) []ContractVal {
-	valKeywords := strings.Split(wholeStr, _sep)
-	contractVals := make([]ContractVal, len(valKeywords))
-	for i, v := range valKeywords {
-		contractVals[i] = stringToContractVal(strings.TrimSpace(v))
+// validate validates the parsed contracts against the signature of the function, and returns the
+// valid ones.
+func (p *contractParser) validate(decls []*contractDecl, sig *types.Signature) []*contractDecl {
+	valid := make([]*contractDecl, 0, len(decls))
+	for _, decl := range decls {
+		if p.validateContract(decl, sig) && p.validateAgainst(decl, valid) {
+			valid = append(valid, decl)
+		}
+	}
+	return valid
+}
+
+// validateContract checks every disjunct of the contract matches the parameters and results of the
+// function, and every value in them is applicable to the type of the corresponding parameter or
+// result.
+func (p *contractParser) validateContract(decl *contractDecl, sig *types.Signature) bool {
+	ok := true
+	for _, d := range append([]*contractDecl{decl}, decl.or...) {
+		ok = p.validateDisjunct(decl, d, sig) && ok
+	}
+	return ok
+}
+
+// validateDisjunct checks a disjunct of the contract (or the contract itself without disjunction)
+// against the signature of the function.
+func (p *contractParser) validateDisjunct(decl *contractDecl, d *contractDecl, sig *types.Signature) bool {
+	if len(d.Ins) != sig.Params().Len() || len(d.Outs) != sig.Results().Len() {
+		p.report(d.pos, "function contract `%s` has %d parameter value(s) and %d result value(s), but function `%s` has %d parameter(s) and %d result(s)",
+			decl, len(d.Ins), len(d.Outs), p.funcName, sig.Params().Len(), sig.Results().Len())
+		return false
+	}
+
+	ok := true
+	for i, val := range d.Ins {
+		t := sig.Params().At(i).Type()
+		switch {
+		case val == True || val == False:
+			p.report(d.valPos[i], "value `%s` for parameter %d in function contract `%s` on function `%s` is not supported, expected `%s`, `%s` or `%s`",
+				val, i, decl, p.funcName, NonNil, Nil, Any)
+			ok = false
+		case (val == NonNil || val == Nil) && util.TypeBarsNilness(t):
+			p.report(d.valPos[i], "value `%s` for parameter %d in function contract `%s` on function `%s` has no effect since its type `%s` can never be nil",
+				val, i, decl, p.funcName, t)
+			ok = false
+		}
+	}
+	for i, val := range d.Outs {
+		t := sig.Results().At(i).Type()
+		pos := d.valPos[len(d.Ins)+i]
+		basic, isBasic := t.Underlying().(*types.Basic)
+		switch {
+		case (val == True || val == False) && !(isBasic && basic.Info()&types.IsBoolean != 0):
+			p.report(pos, "value `%s` for result %d in function contract `%s` on function `%s` requires a boolean result, but its type is `%s`",
+				val, i, decl, p.funcName, t)
+			ok = false
+		case (val == NonNil || val == Nil) && util.TypeBarsNilness(t):
+			p.report(pos, "value `%s` for result %d in function contract `%s` on function `%s` has no effect since its type `%s` can never be nil",
+				val, i, decl, p.funcName, t)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// validateAgainst checks the contract is neither a duplicate of nor contradicting the other
+// contracts of the function. Two contracts without disjunction apply at the same time unless one
+// requires a parameter to be nonnil and the other requires it to be nil, hence they contradict
+// each other if they also guarantee opposite values (i.e., `nonnil` and `nil`, or `true` and
+// `false`) for a result.
+func (p *contractParser) validateAgainst(decl *contractDecl, others []*contractDecl) bool {
+	opposite := func(a, b ContractVal) bool {
+		return (a == True && b == False) || (a == False && b == True) ||
+			(a == NonNil && b == Nil) || (a == Nil && b == NonNil)
+	}
+	for _, other := range others {
+		if decl.String() == other.String() {
+			p.report(decl.pos, "duplicate function contract `%s` on function `%s`", decl, p.funcName)
+			return false
+		}
+		if len(decl.Or) != 0 || len(other.Or) != 0 {
+			continue
+		}
+		applyTogether := true
+		for i, val := range decl.Ins {
+			if (val == NonNil || val == Nil) && opposite(val, other.Ins[i]) {
+				applyTogether = false
+			}
+		}
+		if !applyTogether {
+			continue
+		}
+		for i, val := range decl.Outs {
+			if opposite(val, other.Outs[i]) {
+				// Always describe the opposite values in the same order, i.e., `true` and `false`, or
+				// `nonnil` and `nil`.
+				a, b := val, other.Outs[i]
+				if a == False || a == Nil {
+					a, b = b, a
+				}
+				p.report(decl.pos, "function contract `%s` contradicts `%s` on function `%s`, result %d cannot be both `%s` and `%s`",
+					decl, other, p.funcName, i, a, b)
+				return false
+			}
+		}
 	}
-	return contractVals
+	return true
 }
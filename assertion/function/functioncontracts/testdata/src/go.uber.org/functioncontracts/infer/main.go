@@ -15,6 +15,7 @@
 package infer
 
 import (
+	"errors"
 	"math/rand"
 )
 
@@ -325,3 +326,62 @@ func twoCondsMerge(x *STR) *STR {
 func unknownToUnknownButSameValue(x *int) *int {
 	return x
 }
+
+type K struct{}
+
+type V struct{}
+
+func lookup(ctx any, key *K) (*V, error) {
+	if key == nil {
+		return nil, errors.New("nil key")
+	}
+	return &V{}, nil
+}
+
+func twoParams(x *int, y *int) (*int, *int) {
+	if y == nil {
+		return x, nil
+	}
+	return x, y
+}
+
+func isPresent(x *int) bool {
+	if x == nil {
+		return false
+	}
+	return true
+}
+
+func isAbsent(x *int) bool {
+	if x != nil {
+		return false
+	}
+	return true
+}
+
+func alwaysTrue(x *int) bool {
+	return true
+}
+
+func unknownBool(x *int, b bool) bool {
+	if x == nil {
+		return false
+	}
+	return b
+}
+
+func get(m map[string]*int, k *string) (*int, bool) {
+	if k == nil {
+		return nil, false
+	}
+	return new(int), true
+}
+
+type R struct{}
+
+func (r *R) method(x *int) *int {
+	if x == nil {
+		return nil
+	}
+	return x
+}
@@ -60,6 +60,47 @@ func multipleContracts(x *int, y *int) (*int, bool) {
 	return new(int), true
 }
 
+// The malformed or invalid contracts are dropped, while the valid ones are still read.
+// contract(nonnul -> nonnil)
+// contract(nonnil, _ -> nonnil)
+// contract(nonnil -> nonnil)
+func partiallyInvalid(x *int) *int {
+	return new(int)
+}
+
+// All contracts are invalid, hence the function has no contracts (and none can be inferred).
+// contract(nonnil - > nonnil)
+func invalid(x *int) *int {
+	return new(int)
+}
+
+// The contracts of the exported functions are exported as facts for the downstream packages.
+// contract(nonnil -> nonnil)
+func Exported(x *int) *int { // want Exported:"contract\\(nonnil -> nonnil\\)"
+	return x
+}
+
+// contract(nil -> nil)
+// contract(nonnil -> nonnil)
+func nilToNil(x *int) *int {
+	return x
+}
+
+// Exactly one of the results is nil.
+// contract(_ -> nonnil, nil) | contract(_ -> nil, nonnil)
+func disjunctive(x *int) (*int, *int) {
+	if x == nil {
+		return nil, new(int)
+	}
+	return x, nil
+}
+
+// A disjunctive contract is dropped as a whole if any of its disjuncts is malformed.
+// contract(_ -> nonnil, nil) | contract(_ -> nul, nonnil)
+func malformedDisjunct(x *int) (*int, *int) {
+	return new(int), nil
+}
+
 // This contract `// contract(nonnil -> nonnil)` does not hold for the function because the
 // function has no param or return. Only a contract in its own line should be parsed, not even `//
 // contract(nonnil -> nonnil)`.
@@ -0,0 +1,192 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/diagnostic"
+)
+
+// baselineEntry is a single diagnostic recorded in the baseline file. Note that the file is only
+// informative, the diagnostics are matched by their packages and fingerprints.
+type baselineEntry struct {
+	Package     string `json:"package"`
+	Fingerprint string `json:"fingerprint"`
+	File        string `json:"file"`
+}
+
+// _baselineVersion is the version of the baseline file format, which must be bumped whenever the
+// fingerprints change their meaning such that the stale baselines are rejected instead of silently
+// mismatching the diagnostics. The versions are:
+//   - 1 (files without the version field): fingerprints are computed from the nil paths with the
+//     line numbers removed.
+//   - 2: fingerprints are the stable IDs shown in the messages, computed from the nil sources, the
+//     kinds of the final consumers and the enclosing functions.
+const _baselineVersion = 2
+
+// baselineFile is the content of the baseline file.
+type baselineFile struct {
+	Version int             `json:"version"`
+	Entries []baselineEntry `json:"entries"`
+}
+
+// baselineWriter collects the diagnostics from all analyzed packages and writes them to the
+// baseline file. It is safe for concurrent use.
+type baselineWriter struct {
+	mu sync.Mutex
+	// path is the path of the baseline file.
+	path string
+	// cwd is the current working directory, which the recorded file names are relative to.
+	cwd     string
+	entries []baselineEntry
+}
+
+// newBaselineWriter creates a writer writing to the given path, and writes an empty baseline to
+// the path immediately such that the baseline file is valid even if there are no diagnostics.
+func newBaselineWriter(path, cwd string) (*baselineWriter, error) {
+	w := &baselineWriter{path: path, cwd: cwd}
+	if err := w.flush(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// add records the diagnostic reported in the package at the given position. Diagnostics without
+// fingerprints (e.g., internal errors) are never recorded.
+func (w *baselineWriter) add(pkg string, position token.Position, d diagnostic.Diagnostic) {
+	if d.Fingerprint == "" {
+		return
+	}
+	file := position.Filename
+	if rel, err := filepath.Rel(w.cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = rel
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, baselineEntry{Package: pkg, Fingerprint: d.Fingerprint, File: filepath.ToSlash(file)})
+}
+
+// flush writes all entries recorded so far to the baseline file. Since the singlechecker exits the
+// process directly after the analysis, this has to be called whenever new entries are added to
+// keep the baseline file up-to-date.
+func (w *baselineWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Packages are analyzed concurrently, so we sort the entries for deterministic output.
+	entries := slices.Clone(w.entries)
+	slices.SortFunc(entries, func(a, b baselineEntry) int {
+		if n := cmp.Compare(a.Package, b.Package); n != 0 {
+			return n
+		}
+		if n := cmp.Compare(a.File, b.File); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	if entries == nil {
+		entries = []baselineEntry{}
+	}
+
+	out, err := json.MarshalIndent(baselineFile{Version: _baselineVersion, Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal baseline: %w", err)
+	}
+	if err := os.WriteFile(w.path, append(out, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write baseline to %q: %w", w.path, err)
+	}
+	return nil
+}
+
+// baseline is a loaded baseline file, which stores the known diagnostics that should not be
+// reported again.
+type baseline struct {
+	// entries maps the package paths to the fingerprints and then to the baseline entries.
+	entries map[string]map[string][]baselineEntry
+}
+
+// loadBaseline loads the baseline file from the given path.
+func loadBaseline(path string) (*baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read baseline %q: %w", path, err)
+	}
+	var f baselineFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("parse baseline %q: %w", path, err)
+	}
+	version := max(f.Version, 1)
+	if version != _baselineVersion {
+		return nil, fmt.Errorf("baseline %q has version %d while version %d is expected, since the "+
+			"fingerprints have changed: regenerate it with -write-baseline", path, version, _baselineVersion)
+	}
+
+	b := &baseline{entries: make(map[string]map[string][]baselineEntry)}
+	for _, e := range f.Entries {
+		if b.entries[e.Package] == nil {
+			b.entries[e.Package] = make(map[string][]baselineEntry)
+		}
+		b.entries[e.Package][e.Fingerprint] = append(b.entries[e.Package][e.Fingerprint], e)
+	}
+	return b, nil
+}
+
+// filter returns the diagnostics of the package that are not in the baseline, along with the
+// baseline entries of the package that are no longer reported (i.e., fixed) and can be pruned.
+// Since multiple diagnostics may share the same fingerprint, each baseline entry only matches one
+// diagnostic.
+func (b *baseline) filter(pkg string, diagnostics []diagnostic.Diagnostic) ([]diagnostic.Diagnostic, []baselineEntry) {
+	matched := make(map[string]int)
+	var remaining []diagnostic.Diagnostic
+	for _, d := range diagnostics {
+		if d.Fingerprint != "" && matched[d.Fingerprint] < len(b.entries[pkg][d.Fingerprint]) {
+			matched[d.Fingerprint]++
+			continue
+		}
+		remaining = append(remaining, d)
+	}
+
+	var fixed []baselineEntry
+	for fingerprint, entries := range b.entries[pkg] {
+		fixed = append(fixed, entries[matched[fingerprint]:]...)
+	}
+	slices.SortFunc(fixed, func(a, b baselineEntry) int {
+		if n := cmp.Compare(a.File, b.File); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	return remaining, fixed
+}
+
+// pruneSummary returns the summary of the fixed baseline entries of the package.
+func pruneSummary(pkg string, fixed []baselineEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "nilaway: %d baseline entry(s) of package %q are fixed and can be pruned:\n", len(fixed), pkg)
+	for _, e := range fixed {
+		fmt.Fprintf(&sb, "\t- %s (%s)\n", e.Fingerprint, e.File)
+	}
+	return sb.String()
+}
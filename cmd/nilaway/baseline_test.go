@@ -0,0 +1,91 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/diagnostic"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestBaseline(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	w, err := newBaselineWriter(path, "/src")
+	require.NoError(t, err)
+
+	// An empty baseline should be written immediately.
+	b, err := loadBaseline(path)
+	require.NoError(t, err)
+	require.Empty(t, b.entries)
+
+	diag := func(fingerprint, msg string) diagnostic.Diagnostic {
+		return diagnostic.Diagnostic{Diagnostic: analysis.Diagnostic{Message: msg}, Fingerprint: fingerprint}
+	}
+	pos := token.Position{Filename: "/src/pkg/a.go", Line: 1, Column: 1}
+	w.add("pkg", pos, diag("aaa", "1"))
+	w.add("pkg", pos, diag("aaa", "2"))
+	w.add("pkg", pos, diag("bbb", "3"))
+	w.add("other", pos, diag("ccc", "4"))
+	// Diagnostics without fingerprints (e.g., internal errors) should not be recorded.
+	w.add("pkg", pos, diag("", "5"))
+	require.NoError(t, w.flush())
+
+	b, err = loadBaseline(path)
+	require.NoError(t, err)
+	require.Len(t, b.entries["pkg"]["aaa"], 2)
+	require.Equal(t, "pkg/a.go", b.entries["pkg"]["aaa"][0].File)
+
+	// Each baseline entry should only match one diagnostic, and the unmatched entries should be
+	// reported as fixed.
+	remaining, fixed := b.filter("pkg", []diagnostic.Diagnostic{
+		diag("aaa", "known"),
+		diag("aaa", "known"),
+		diag("aaa", "new, since there are only two entries in the baseline"),
+		diag("ccc", "new, since the fingerprint is in another package"),
+		diag("", "internal error"),
+	})
+	var messages []string
+	for _, d := range remaining {
+		messages = append(messages, d.Message)
+	}
+	require.Equal(t, []string{
+		"new, since there are only two entries in the baseline",
+		"new, since the fingerprint is in another package",
+		"internal error",
+	}, messages)
+	require.Equal(t, []baselineEntry{{Package: "pkg", Fingerprint: "bbb", File: "pkg/a.go"}}, fixed)
+}
+
+func TestBaseline_Version(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{
+		// Baselines without the version field have the path-based fingerprints.
+		`{"entries": [{"package": "pkg", "fingerprint": "pkg/a.go: literal nil dereferenced"}]}`,
+		`{"version": 3, "entries": []}`,
+	} {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := loadBaseline(path)
+		require.ErrorContains(t, err, "regenerate it with -write-baseline", content)
+	}
+}
@@ -0,0 +1,187 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/nilaway/diagnostic"
+)
+
+// _hunkHeaderRegex matches the hunk headers (e.g., "@@ -1,3 +1,4 @@") in unified diffs.
+var _hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// diffFilter keeps only the diagnostics whose nil flows touch the lines changed in a unified diff.
+type diffFilter struct {
+	// cwd is the current working directory, which the relative file names are relative to.
+	cwd string
+	// lines maps the absolute file names to the changed line numbers in the new version of the
+	// files.
+	lines map[string]map[int]bool
+}
+
+// loadDiff reads the unified diff from the given path ("-" for stdin), where the file names in
+// the diff are relative to the given working directory.
+func loadDiff(path, cwd string) (*diffFilter, error) {
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open diff %q: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+	filter, err := parseUnifiedDiff(r, cwd)
+	if err != nil {
+		return nil, fmt.Errorf("parse diff %q: %w", path, err)
+	}
+	return filter, nil
+}
+
+// parseUnifiedDiff parses the unified diff and collects the changed lines in the new version of
+// the files. The added lines are marked as changed, and for removed lines we mark the line where
+// the removal happens in the new version, such that removing code (e.g., a nil check) still
+// counts as changing the surrounding lines.
+func parseUnifiedDiff(r io.Reader, cwd string) (*diffFilter, error) {
+	filter := &diffFilter{cwd: cwd, lines: make(map[string]map[int]bool)}
+
+	var (
+		// files are the absolute names of the file currently being parsed, see below for why
+		// there could be multiple candidates.
+		files []string
+		// line is the current line number in the new version of the file.
+		line int
+		// oldRemaining and newRemaining are the remaining number of lines in the current hunk for
+		// the old and new versions of the file, respectively.
+		oldRemaining, newRemaining int
+	)
+	mark := func(line int) {
+		for _, f := range files {
+			if filter.lines[f] == nil {
+				filter.lines[f] = make(map[int]bool)
+			}
+			filter.lines[f][line] = true
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		// Lines within hunks.
+		if oldRemaining > 0 || newRemaining > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				mark(line)
+				line++
+				newRemaining--
+			case strings.HasPrefix(text, "-"):
+				mark(line)
+				oldRemaining--
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file".
+			default:
+				// Context lines (some tools trim the leading space of empty context lines).
+				line++
+				oldRemaining--
+				newRemaining--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			name, _, _ := strings.Cut(strings.TrimPrefix(text, "+++ "), "\t")
+			files = nil
+			if name == "/dev/null" {
+				// The file is deleted, nothing in the new version is changed.
+				continue
+			}
+			// Diffs generated by git prefix the file names with "b/" by default, but we cannot
+			// tell if it is such a prefix or a real directory, so we keep both candidates.
+			files = append(files, filter.abs(name))
+			if stripped, ok := strings.CutPrefix(name, "b/"); ok {
+				files = append(files, filter.abs(stripped))
+			}
+		case strings.HasPrefix(text, "@@ "):
+			m := _hunkHeaderRegex.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", text)
+			}
+			oldRemaining, newRemaining = 1, 1
+			if m[1] != "" {
+				oldRemaining, _ = strconv.Atoi(m[1])
+			}
+			line, _ = strconv.Atoi(m[2])
+			if m[3] != "" {
+				newRemaining, _ = strconv.Atoi(m[3])
+			}
+			if newRemaining == 0 {
+				// For pure removals, the start line is the line before the removal.
+				line++
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// abs returns the absolute file name of the (possibly relative) file name.
+func (f *diffFilter) abs(name string) string {
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(f.cwd, filepath.FromSlash(name))
+}
+
+// touches returns true if the diagnostic reported at the given position, or any of the producer
+// and consumer positions in its nil flows (including the similar ones grouped under it), falls on
+// the changed lines. This way, a nil flow introduced by a change in one file is still reported
+// even if the dereference lives in an unchanged file.
+func (f *diffFilter) touches(position token.Position, d diagnostic.Diagnostic) bool {
+	if f.touchesPosition(position) {
+		return true
+	}
+	for _, flow := range append([]diagnostic.Flow{d.Flow}, d.SimilarFlows...) {
+		for _, path := range [...][]diagnostic.FlowNode{flow.NilPath, flow.NonNilPath} {
+			for _, n := range path {
+				if f.touchesPosition(n.ProducerPosition) || f.touchesPosition(n.ConsumerPosition) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// touchesPosition returns true if the position falls on the changed lines.
+func (f *diffFilter) touchesPosition(position token.Position) bool {
+	if !position.IsValid() {
+		return false
+	}
+	return f.lines[f.abs(position.Filename)][position.Line]
+}
@@ -0,0 +1,92 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/diagnostic"
+)
+
+const _testDiff = `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -1,4 +1,4 @@
+ package pkg
+ 
+-func foo() *int { return new(int) }
++func foo() *int { return nil }
+ 
+@@ -10,3 +10,2 @@ func bar() {
+ 	x := foo()
+-	if x == nil { return }
+ 	print(*x)
+@@ -20,0 +20,2 @@ func baz() {
++	var y *int
++	print(*y)
+diff --git a/pkg/deleted.go b/pkg/deleted.go
+--- a/pkg/deleted.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package pkg
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	filter, err := parseUnifiedDiff(strings.NewReader(_testDiff), "/src")
+	require.NoError(t, err)
+
+	// Both the file name with and without the "b/" prefix should be recorded.
+	expected := map[int]bool{3: true, 11: true, 20: true, 21: true}
+	require.Equal(t, map[string]map[int]bool{
+		"/src/b/pkg/a.go": expected,
+		"/src/pkg/a.go":   expected,
+	}, filter.lines)
+}
+
+func TestDiffFilter_Touches(t *testing.T) {
+	t.Parallel()
+
+	filter, err := parseUnifiedDiff(strings.NewReader(_testDiff), "/src")
+	require.NoError(t, err)
+
+	pos := func(file string, line int) token.Position {
+		return token.Position{Filename: file, Line: line, Column: 1}
+	}
+	// The changed `return nil` is on the nil path, while the dereference lives in another file.
+	d := diagnostic.Diagnostic{Flow: diagnostic.Flow{
+		NilPath:    []diagnostic.FlowNode{{ConsumerPosition: pos("pkg/a.go", 3)}},
+		NonNilPath: []diagnostic.FlowNode{{ConsumerPosition: pos("pkg/other.go", 5)}},
+	}}
+	require.True(t, filter.touches(pos("/src/pkg/other.go", 5), d))
+
+	// Nothing in the flow is changed.
+	d = diagnostic.Diagnostic{Flow: diagnostic.Flow{
+		NonNilPath: []diagnostic.FlowNode{{ProducerPosition: pos("pkg/a.go", 2), ConsumerPosition: pos("pkg/other.go", 5)}},
+	}}
+	require.False(t, filter.touches(pos("/src/pkg/other.go", 5), d))
+
+	// The similar flows grouped under the diagnostic are also checked.
+	d.SimilarFlows = []diagnostic.Flow{{NonNilPath: []diagnostic.FlowNode{{ConsumerPosition: pos("/src/pkg/a.go", 21)}}}}
+	require.True(t, filter.touches(pos("/src/pkg/other.go", 5), d))
+
+	// The reported position itself is also checked.
+	require.True(t, filter.touches(pos("/src/pkg/a.go", 20), diagnostic.Diagnostic{}))
+}
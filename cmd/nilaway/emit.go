@@ -0,0 +1,256 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/inference"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _annotatableNameRegex matches the names that can be referred to in the nilability annotations,
+// which must be kept in sync with the identifiers accepted by the annotation parser.
+var _annotatableNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
+
+// siteLookup returns the nilability of the shallow (or deep) site of the key, and whether it is
+// determined by the inference. Sites that are undetermined or determined by the annotations
+// (including the stubs) should not be emitted.
+type siteLookup func(key annotation.Key, isDeep bool) (nilable bool, ok bool)
+
+// inferredSiteLookup returns the siteLookup for the sites determined by the inference in the map.
+func inferredSiteLookup(m *inference.InferredMap) siteLookup {
+	return func(key annotation.Key, isDeep bool) (bool, bool) {
+		switch val := m.Determined(key, isDeep).(type) {
+		case nil, inference.TrueBecauseAnnotation, inference.FalseBecauseAnnotation:
+			return false, false
+		default:
+			return val.Val(), true
+		}
+	}
+}
+
+// emitAnnotations returns a diagnostic for each exported function, method and struct type whose
+// sites have inferred nilabilities, along with the suggested fix that writes them back to the doc
+// comment as nilability annotations (e.g., `// nilable(result 0)`).
+func emitAnnotations(pass *analysis.Pass, lookup siteLookup) []analysis.Diagnostic {
+	var diagnostics []analysis.Diagnostic
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+				if !ok || !isExportedFunc(fn) {
+					continue
+				}
+				set := &annotationSet{lookup: lookup}
+				sig := fn.Type().(*types.Signature)
+				if recv := sig.Recv(); recv != nil {
+					set.add(&annotation.RecvAnnotationKey{FuncDecl: fn}, recv, "")
+				}
+				for i := 0; i < sig.Params().Len(); i++ {
+					set.add(annotation.ParamKeyFromArgNum(fn, i), sig.Params().At(i), fmt.Sprintf("param %d", i))
+				}
+				for i := 0; i < sig.Results().Len(); i++ {
+					set.add(annotation.RetKeyFromRetNum(fn, i), sig.Results().At(i), fmt.Sprintf("result %d", i))
+				}
+				name := fn.Name()
+				if recv := sig.Recv(); recv != nil {
+					name = recvTypeName(recv.Type()).Name() + "." + name
+				}
+				if d, ok := set.diagnostic(pass.Fset, decl.Name.Pos(), name, decl, decl.Doc); ok {
+					diagnostics = append(diagnostics, d)
+				}
+
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					st, ok := spec.Type.(*ast.StructType)
+					if !ok || !spec.Name.IsExported() {
+						continue
+					}
+					set := &annotationSet{lookup: lookup}
+					for _, field := range st.Fields.List {
+						for _, name := range field.Names {
+							v, ok := pass.TypesInfo.Defs[name].(*types.Var)
+							if ok && v.Exported() {
+								set.add(&annotation.FieldAnnotationKey{FieldDecl: v}, v, "")
+							}
+						}
+					}
+					// Same as the annotation parser, the doc comment of the declaration is used if it
+					// only declares a single type.
+					var node ast.Node = spec
+					doc := spec.Doc
+					if len(decl.Specs) == 1 {
+						node, doc = decl, decl.Doc
+					}
+					if d, ok := set.diagnostic(pass.Fset, spec.Name.Pos(), spec.Name.Name, node, doc); ok {
+						diagnostics = append(diagnostics, d)
+					}
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+// isExportedFunc returns true if the function is exported, or if it is an exported method of an
+// exported type.
+func isExportedFunc(fn *types.Func) bool {
+	if !fn.Exported() {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return true
+	}
+	tn := recvTypeName(recv.Type())
+	return tn != nil && tn.Exported()
+}
+
+// recvTypeName returns the type name of the receiver type, or nil if it is not a named type.
+func recvTypeName(t types.Type) *types.TypeName {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok {
+		return named.Obj()
+	}
+	return nil
+}
+
+// annotatedName returns the name referring to the variable in the annotations, which is the given
+// one (e.g., "param 0") if the variable is unnamed. False is returned if the variable cannot be
+// referred to in the annotations (e.g., "_" or the names containing underscores).
+func annotatedName(v *types.Var, unnamed string) (string, bool) {
+	if v.Name() == "" {
+		return unnamed, unnamed != ""
+	}
+	return v.Name(), _annotatableNameRegex.MatchString(v.Name())
+}
+
+// annotationSet collects the names of the sites of a declaration to be annotated as nilable and
+// nonnil.
+type annotationSet struct {
+	lookup  siteLookup
+	nilable []string
+	nonnil  []string
+}
+
+// add adds the shallow and deep (and deeper) sites of the key for the variable whose nilabilities are inferred,
+// where unnamed is the name referring to the variable if it is unnamed (see annotatedName). The
+// sites whose types can never be nil are skipped.
+func (s *annotationSet) add(key annotation.Key, v *types.Var, unnamed string) {
+	name, ok := annotatedName(v, unnamed)
+	if !ok {
+		return
+	}
+	typ := v.Type()
+
+	if !util.TypeBarsNilness(typ.Underlying()) {
+		if nilable, ok := s.lookup(key, false); ok {
+			s.append(nilable, name)
+		}
+	}
+
+	// The deep (and deeper) sites are annotated with the markers of their containers, e.g., `*x`
+	// for the deep site of a pointer `x`, and `x[][]` for the elements of the slices in `x`. Same
+	// as the default deep nilability, the multi-dimensional arrays are deeply nilable if their
+	// innermost elements are (see annotation.ElemTypes).
+	elems := annotation.ElemTypes(typ)
+	prefix, suffix := "", ""
+	for depth := 1; depth < len(elems); depth++ {
+		switch elems[depth-1].Underlying().(type) {
+		case *types.Pointer:
+			prefix += "*"
+		case *types.Chan:
+			prefix += "<-"
+		default:
+			suffix += "[]"
+		}
+		if util.TypeBarsNilness(elems[depth].Underlying()) {
+			continue
+		}
+		elemKey := key
+		if depth > 1 {
+			elemKey = &annotation.ElemAnnotationKey{Base: key, Depth: depth - 1}
+		}
+		if nilable, ok := s.lookup(elemKey, true); ok {
+			s.append(nilable, prefix+name+suffix)
+		}
+	}
+}
+
+func (s *annotationSet) append(nilable bool, name string) {
+	if nilable {
+		s.nilable = append(s.nilable, name)
+	} else {
+		s.nonnil = append(s.nonnil, name)
+	}
+}
+
+// annotations returns the nilability annotations of the collected sites.
+func (s *annotationSet) annotations() []string {
+	var annotations []string
+	if len(s.nilable) > 0 {
+		annotations = append(annotations, fmt.Sprintf("nilable(%s)", strings.Join(s.nilable, ", ")))
+	}
+	if len(s.nonnil) > 0 {
+		annotations = append(annotations, fmt.Sprintf("nonnil(%s)", strings.Join(s.nonnil, ", ")))
+	}
+	return annotations
+}
+
+// diagnostic returns the diagnostic (at the given position) with the suggested fix that appends
+// the annotations to the doc comment of the node, or inserts them as a new doc comment if the node
+// has none. Since the positions only contain the columns, the code is assumed to be gofmt-ed
+// (i.e., indented by tabs). False is returned if there is nothing to annotate.
+func (s *annotationSet) diagnostic(fset *token.FileSet, pos token.Pos, name string, node ast.Node, doc *ast.CommentGroup) (analysis.Diagnostic, bool) {
+	annotations := s.annotations()
+	if len(annotations) == 0 {
+		return analysis.Diagnostic{}, false
+	}
+
+	var edit analysis.TextEdit
+	if doc != nil {
+		indent := strings.Repeat("\t", fset.Position(doc.Pos()).Column-1)
+		text := "\n" + indent + "// " + strings.Join(annotations, "\n"+indent+"// ")
+		edit = analysis.TextEdit{Pos: doc.End(), End: doc.End(), NewText: []byte(text)}
+	} else {
+		indent := strings.Repeat("\t", fset.Position(node.Pos()).Column-1)
+		text := "// " + strings.Join(annotations, "\n"+indent+"// ") + "\n" + indent
+		edit = analysis.TextEdit{Pos: node.Pos(), End: node.Pos(), NewText: []byte(text)}
+	}
+
+	return analysis.Diagnostic{
+		Pos:     pos,
+		Message: fmt.Sprintf("inferred nilability annotations for `%s`: %s", name, strings.Join(annotations, " ")),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "Add the inferred nilability annotations to the doc comment",
+			TextEdits: []analysis.TextEdit{edit},
+		}},
+	}, true
+}
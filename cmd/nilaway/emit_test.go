@@ -0,0 +1,162 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/annotation"
+	"golang.org/x/tools/go/analysis"
+)
+
+const _testEmitSrc = `package pkg
+
+// Func does something.
+func Func(a *int, b int, _ *int) (*int, []*int) { return nil, nil }
+
+func Unnamed(*int) (x_y *int) { return nil }
+
+func unexported(a *int) {}
+
+type T struct {
+	F *int
+	G []*int
+	N [][]*int
+	h *int
+}
+
+func (t *T) Method(p *int) {}
+
+func (t *t) Method(p *int) {}
+
+type (
+	// U is a struct.
+	U struct {
+		F *int
+	}
+
+	t struct {
+		F *int
+	}
+
+	V struct {
+		F *int
+	}
+)
+`
+
+const _testEmitWant = `package pkg
+
+// Func does something.
+// nilable(result 0, result 1, result 1[])
+// nonnil(a)
+func Func(a *int, b int, _ *int) (*int, []*int) { return nil, nil }
+
+// nonnil(param 0)
+func Unnamed(*int) (x_y *int) { return nil }
+
+func unexported(a *int) {}
+
+// nilable(F, N, N[])
+// nonnil(G, G[], N[][])
+type T struct {
+	F *int
+	G []*int
+	N [][]*int
+	h *int
+}
+
+// nilable(p)
+// nonnil(t)
+func (t *T) Method(p *int) {}
+
+func (t *t) Method(p *int) {}
+
+type (
+	// U is a struct.
+	// nilable(F)
+	U struct {
+		F *int
+	}
+
+	t struct {
+		F *int
+	}
+
+	// nilable(F)
+	V struct {
+		F *int
+	}
+)
+`
+
+func TestEmitAnnotations(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pkg.go", _testEmitSrc, parser.ParseComments)
+	require.NoError(t, err)
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	pkg, err := new(types.Config).Check("example.com/pkg", fset, []*ast.File{file}, info)
+	require.NoError(t, err)
+	pass := &analysis.Pass{Fset: fset, Files: []*ast.File{file}, Pkg: pkg, TypesInfo: info}
+
+	// All fields are inferred nilable, and all other sites nonnil, except that the deep results
+	// are inferred nilable, the parameter "b" is not inferred (although it can never be nil), and
+	// the nested elements beyond the deep ones are inferred nonnil.
+	lookup := func(key annotation.Key, isDeep bool) (bool, bool) {
+		switch key := key.(type) {
+		case *annotation.FieldAnnotationKey:
+			return key.FieldDecl.Name() != "G", true
+		case *annotation.ParamAnnotationKey:
+			return key.FuncDecl.Name() == "Method" && !isDeep, key.ParamName().Name() != "b"
+		case *annotation.RetAnnotationKey:
+			return isDeep || key.FuncDecl.Name() == "Func", true
+		}
+		return false, true
+	}
+	diagnostics := emitAnnotations(pass, lookup)
+
+	var messages []string
+	var edits []analysis.TextEdit
+	for _, d := range diagnostics {
+		messages = append(messages, d.Message)
+		require.Len(t, d.SuggestedFixes, 1)
+		edits = append(edits, d.SuggestedFixes[0].TextEdits...)
+	}
+	require.Equal(t, []string{
+		"inferred nilability annotations for `Func`: nilable(result 0, result 1, result 1[]) nonnil(a)",
+		"inferred nilability annotations for `Unnamed`: nonnil(param 0)",
+		"inferred nilability annotations for `T`: nilable(F, N, N[]) nonnil(G, G[], N[][])",
+		"inferred nilability annotations for `T.Method`: nilable(p) nonnil(t)",
+		"inferred nilability annotations for `U`: nilable(F)",
+		"inferred nilability annotations for `V`: nilable(F)",
+	}, messages)
+
+	// Apply the edits in reverse order to keep the offsets of the earlier edits valid.
+	slices.SortFunc(edits, func(a, b analysis.TextEdit) int { return int(b.Pos - a.Pos) })
+	src := _testEmitSrc
+	for _, e := range edits {
+		offset := fset.Position(e.Pos).Offset
+		src = src[:offset] + string(e.NewText) + src[fset.Position(e.End).Offset:]
+	}
+	require.Equal(t, _testEmitWant, src)
+}
@@ -0,0 +1,194 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/inference"
+)
+
+// explainQuery is a parsed query for explaining the nilability of a site, which is one of:
+//   - "<pkg>.<Func> result <N>" or "<pkg>.<Type>.<Method> result <N>" for function results;
+//   - "<pkg>.<Func> param <N>" or "<pkg>.<Type>.<Method> param <N>" for function parameters;
+//   - "<pkg>.<Type>.<field>" for struct fields;
+//   - "<pkg>.<Var>" for global variables.
+type explainQuery struct {
+	// query is the original query string.
+	query string
+	// pkgPath is the path of the package the site resides in.
+	pkgPath string
+	// names are the name of the package-level object, optionally followed by the name of the
+	// field or method.
+	names []string
+	// kind is "result" or "param" for function sites, and empty otherwise.
+	kind string
+	// index is the index of the result or parameter.
+	index int
+}
+
+// parseExplainQuery parses the query, see explainQuery for the supported forms.
+func parseExplainQuery(query string) (*explainQuery, error) {
+	fields := strings.Fields(query)
+	if len(fields) != 1 && len(fields) != 3 {
+		return nil, fmt.Errorf("invalid query %q: expect \"<pkg>.<name>[.<member>] [result|param <N>]\"", query)
+	}
+
+	// The package path may contain dots (e.g., "go.uber.org/nilaway"), so the package path ends
+	// at the first dot after the last slash.
+	path := fields[0]
+	slash := strings.LastIndex(path, "/")
+	dot := strings.Index(path[slash+1:], ".")
+	if dot < 0 {
+		return nil, fmt.Errorf("invalid query %q: missing package path", query)
+	}
+	q := &explainQuery{query: query, pkgPath: path[:slash+1+dot]}
+	q.names = strings.Split(path[slash+1+dot+1:], ".")
+	if len(q.names) > 2 || slices.Contains(q.names, "") {
+		return nil, fmt.Errorf("invalid query %q: expect \"<name>\" or \"<name>.<member>\" after the package path", query)
+	}
+
+	if len(fields) == 3 {
+		q.kind = fields[1]
+		if q.kind != "result" && q.kind != "param" {
+			return nil, fmt.Errorf("invalid query %q: expect \"result\" or \"param\", got %q", query, q.kind)
+		}
+		index, err := strconv.Atoi(fields[2])
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid query %q: invalid index %q", query, fields[2])
+		}
+		q.index = index
+	}
+	return q, nil
+}
+
+// pkg returns the package the site resides in, if it is the given package or one of its direct
+// imports. Nil is returned otherwise.
+func (q *explainQuery) pkg(pkg *types.Package) *types.Package {
+	if pkg.Path() == q.pkgPath {
+		return pkg
+	}
+	for _, imported := range pkg.Imports() {
+		if imported.Path() == q.pkgPath {
+			return imported
+		}
+	}
+	return nil
+}
+
+// key resolves the site in the package to its annotation key.
+func (q *explainQuery) key(pkg *types.Package) (annotation.Key, error) {
+	obj := pkg.Scope().Lookup(q.names[0])
+	if obj == nil {
+		return nil, fmt.Errorf("%q not found in package %q", q.names[0], q.pkgPath)
+	}
+	if len(q.names) == 2 {
+		tn, ok := obj.(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("%q in package %q is not a type", q.names[0], q.pkgPath)
+		}
+		obj, _, _ = types.LookupFieldOrMethod(tn.Type(), true /* addressable */, pkg, q.names[1])
+		if obj == nil {
+			return nil, fmt.Errorf("field or method %q not found in type %q", q.names[1], tn.Name())
+		}
+	}
+
+	if q.kind != "" {
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a function or method", obj.Name())
+		}
+		sig := fn.Type().(*types.Signature)
+		if q.kind == "result" {
+			if q.index >= sig.Results().Len() {
+				return nil, fmt.Errorf("function %q has only %d result(s)", fn.Name(), sig.Results().Len())
+			}
+			return annotation.RetKeyFromRetNum(fn, q.index), nil
+		}
+		if q.index >= sig.Params().Len() {
+			return nil, fmt.Errorf("function %q has only %d parameter(s)", fn.Name(), sig.Params().Len())
+		}
+		return annotation.ParamKeyFromArgNum(fn, q.index), nil
+	}
+
+	switch obj := obj.(type) {
+	case *types.Var:
+		if obj.IsField() {
+			return &annotation.FieldAnnotationKey{FieldDecl: obj}, nil
+		}
+		return &annotation.GlobalVarAnnotationKey{VarDecl: obj}, nil
+	case *types.Func:
+		return nil, fmt.Errorf("%q is a function, specify \"result <N>\" or \"param <N>\"", obj.Name())
+	default:
+		return nil, fmt.Errorf("%q is not a function, field, or global variable", obj.Name())
+	}
+}
+
+// explainer prints the explanations of the site in the query. It is safe for concurrent use.
+type explainer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	query *explainQuery
+}
+
+// explain prints the explanations of the site as of the analysis of the given package. Since the
+// sites could be further determined by the downstream packages, the explanations are printed for
+// the package the site resides in, as well as any other packages that determine the site.
+func (e *explainer) explain(pkg string, explanations []inference.SiteExplanation) {
+	var sb strings.Builder
+	for _, exp := range explanations {
+		if pkg != e.query.pkgPath && (exp.Explanation == nil || exp.DeterminedBy != "") {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s (as of package %q):\n", exp.Site, pkg)
+		if exp.Explanation == nil {
+			sb.WriteString("\tUNDETERMINED (only constrained by the implications with other undetermined sites)\n")
+			continue
+		}
+
+		val := "NONNIL"
+		if exp.Explanation.Val() {
+			val = "NILABLE"
+		}
+		determinedBy := "this package"
+		if exp.DeterminedBy != "" {
+			determinedBy = fmt.Sprintf("upstream package %q", exp.DeterminedBy)
+		}
+		fmt.Fprintf(&sb, "\t%s, determined by %s, because:\n", val, determinedBy)
+		for r := exp.Explanation; r != nil; r = r.DeeperReason() {
+			producer, consumer := r.TriggerReprs()
+			if producer != nil && consumer != nil {
+				// The representations already contain the positions.
+				fmt.Fprintf(&sb, "\t- %s %s\n", producer, consumer)
+			} else {
+				fmt.Fprintf(&sb, "\t- %s: %s\n", r.Position(), r)
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, _ = io.WriteString(e.w, sb.String())
+}
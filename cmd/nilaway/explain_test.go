@@ -0,0 +1,146 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/inference"
+)
+
+const _testExplainSrc = `package pkg
+
+var Global *int
+
+type T struct {
+	f *int
+}
+
+func (t *T) Method(a, b *int) *int { return nil }
+
+func Func() (*int, error) { return nil, nil }
+`
+
+func TestParseExplainQuery(t *testing.T) {
+	t.Parallel()
+
+	q, err := parseExplainQuery("go.uber.org/foo/bar.T.Method result 0")
+	require.NoError(t, err)
+	require.Equal(t, "go.uber.org/foo/bar", q.pkgPath)
+	require.Equal(t, []string{"T", "Method"}, q.names)
+	require.Equal(t, "result", q.kind)
+	require.Equal(t, 0, q.index)
+
+	q, err = parseExplainQuery("fmt.Stringer")
+	require.NoError(t, err)
+	require.Equal(t, "fmt", q.pkgPath)
+	require.Equal(t, []string{"Stringer"}, q.names)
+	require.Empty(t, q.kind)
+
+	for _, invalid := range []string{
+		"",
+		"go.uber.org/foo",
+		"pkg.Func result",
+		"pkg.Func return 0",
+		"pkg.Func param -1",
+		"pkg.A.B.C",
+		"pkg.T.",
+	} {
+		_, err := parseExplainQuery(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestExplainQueryKey(t *testing.T) {
+	t.Parallel()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "pkg.go", _testExplainSrc, 0)
+	require.NoError(t, err)
+	pkg, err := new(types.Config).Check("example.com/pkg", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+
+	for query, want := range map[string]string{
+		"example.com/pkg.Func result 1":     "Result 1 of Function Func",
+		"example.com/pkg.T.Method param 1":  "Param 1: 'b' of Function Method",
+		"example.com/pkg.T.Method result 0": "Result 0 of Function Method",
+		"example.com/pkg.T.f":               "Field f",
+		"example.com/pkg.Global":            "Global Variable Global",
+	} {
+		q, err := parseExplainQuery(query)
+		require.NoError(t, err, query)
+		require.Equal(t, pkg, q.pkg(pkg))
+		key, err := q.key(pkg)
+		require.NoError(t, err, query)
+		require.Equal(t, want, key.String(), query)
+	}
+
+	for _, query := range []string{
+		"example.com/pkg.Missing",
+		"example.com/pkg.Func",
+		"example.com/pkg.Func result 2",
+		"example.com/pkg.T.Method param 2",
+		"example.com/pkg.T.missing",
+		"example.com/pkg.Global.f",
+		"example.com/pkg.Global result 0",
+	} {
+		q, err := parseExplainQuery(query)
+		require.NoError(t, err, query)
+		_, err = q.key(pkg)
+		require.Error(t, err, query)
+	}
+
+	q, err := parseExplainQuery("example.com/other.Func result 0")
+	require.NoError(t, err)
+	require.Nil(t, q.pkg(pkg))
+}
+
+func TestExplainer(t *testing.T) {
+	t.Parallel()
+
+	q, err := parseExplainQuery("example.com/pkg.Global")
+	require.NoError(t, err)
+	var out strings.Builder
+	e := &explainer{w: &out, query: q}
+
+	annotated := inference.TrueBecauseAnnotation{AnnotationPos: token.Position{Filename: "pkg.go", Line: 3, Column: 1}}
+	explanations := []inference.SiteExplanation{
+		{Site: "Global Variable Global", Explanation: annotated, DeterminedBy: "example.com/upstream"},
+		{Site: "Deep Global Variable Global"},
+	}
+	e.explain("example.com/pkg", explanations)
+	require.Equal(t, `Global Variable Global (as of package "example.com/pkg"):
+	NILABLE, determined by upstream package "example.com/upstream", because:
+	- pkg.go:3:1: NILABLE because it is annotated as so
+Deep Global Variable Global (as of package "example.com/pkg"):
+	UNDETERMINED (only constrained by the implications with other undetermined sites)
+`, out.String())
+
+	// Other packages should only print the explanations of the sites they determine.
+	out.Reset()
+	e.explain("example.com/downstream", explanations)
+	require.Empty(t, out.String())
+	e.explain("example.com/downstream", []inference.SiteExplanation{{Site: "Global Variable Global", Explanation: annotated}})
+	require.Equal(t, `Global Variable Global (as of package "example.com/downstream"):
+	NILABLE, determined by this package, because:
+	- pkg.go:3:1: NILABLE because it is annotated as so
+`, out.String())
+}
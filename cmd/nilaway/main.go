@@ -20,12 +20,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"go/token"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"go.uber.org/nilaway"
+	"go.uber.org/nilaway/accumulation"
 	"go.uber.org/nilaway/config"
+	"go.uber.org/nilaway/diagnostic"
+	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
@@ -47,6 +52,36 @@ var (
 	_includeErrorsInFiles string
 	// _excludeErrorsInFiles is a driver flag for specifying the list of file prefixes to not report errors.
 	_excludeErrorsInFiles string
+	// _sarif is a driver flag for specifying the file to write the diagnostics in SARIF format.
+	_sarif string
+	// _sarifRecorder lazily creates (since the flags are parsed by the singlechecker) the recorder
+	// that collects the diagnostics to be written in SARIF format. The recorder is nil if the SARIF
+	// output is not requested.
+	_sarifRecorder func() (*sarifRecorder, error)
+	// _writeBaseline is a driver flag for specifying the file to write the baseline to.
+	_writeBaseline string
+	// _baselineWriter lazily creates the writer for the baseline file, it is nil if writing the
+	// baseline is not requested.
+	_baselineWriter func() (*baselineWriter, error)
+	// _baselinePath is a driver flag for specifying the baseline file of the known diagnostics.
+	_baselinePath string
+	// _baseline lazily loads the baseline file, it is nil if no baseline is given.
+	_baseline func() (*baseline, error)
+	// _patch is a driver flag for specifying the unified diff file ("-" for stdin) to only report
+	// the diagnostics whose nil flows touch the changed lines. Note that it is not named "-diff"
+	// since that is taken by newer versions of the analysis drivers.
+	_patch string
+	// _diffFilter lazily loads the diff, it is nil if no diff is given.
+	_diffFilter func() (*diffFilter, error)
+	// _explain is a driver flag for specifying the query of the site to explain the nilability of
+	// (see explainQuery for the supported forms).
+	_explain string
+	// _explainer lazily parses the query, it is nil if no query is given.
+	_explainer func() (*explainer, error)
+	// _emitAnnotations is a driver flag for reporting the inferred nilabilities of the exported
+	// functions, methods and struct fields (with the suggested fixes that write them back to the
+	// doc comments as nilability annotations) instead of the errors.
+	_emitAnnotations bool
 )
 
 func run(pass *analysis.Pass) (interface{}, error) {
@@ -67,26 +102,150 @@ func run(pass *analysis.Pass) (interface{}, error) {
 		return nil, fmt.Errorf("parse file prefixes for error exclusion: %w", err)
 	}
 
-	// Override the report function to add error filtering logic.
-	report := pass.Report
-	pass.Report = func(d analysis.Diagnostic) {
+	shouldReport := func(d analysis.Diagnostic) bool {
 		p := pass.Fset.File(d.Pos).Name()
 		for _, e := range excludes {
 			if strings.HasPrefix(p, e) {
-				return
+				return false
 			}
 		}
 
 		for _, i := range includes {
 			if strings.HasPrefix(p, i) {
-				report(d)
-				return
+				return true
 			}
 		}
+		return false
+	}
+
+	sarif, err := _sarifRecorder()
+	if err != nil {
+		return nil, fmt.Errorf("set up SARIF output: %w", err)
+	}
+	writer, err := _baselineWriter()
+	if err != nil {
+		return nil, fmt.Errorf("set up baseline output: %w", err)
+	}
+	base, err := _baseline()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := _diffFilter()
+	if err != nil {
+		return nil, err
+	}
+	explain, err := _explainer()
+	if err != nil {
+		return nil, err
 	}
 
+	res := pass.ResultOf[accumulation.Analyzer].(*accumulation.Result)
+	if explain != nil {
+		// The query mode only interrogates the inferred map, and does not report any diagnostics.
+		if pkg := explain.query.pkg(pass.Pkg); pkg != nil && res.InferredMap != nil {
+			key, err := explain.query.key(pkg)
+			if err != nil {
+				if pkg != pass.Pkg {
+					// Only report the resolution errors once in the package the site resides in.
+					return nil, nil
+				}
+				return nil, fmt.Errorf("explain %q: %w", explain.query.query, err)
+			}
+			explain.explain(pass.Pkg.Path(), res.InferredMap.Explain(key))
+		}
+		return nil, nil
+	}
+	if _emitAnnotations {
+		// Similarly, the emitting mode only reports the inferred annotations instead of errors.
+		if res.InferredMap != nil {
+			for _, d := range emitAnnotations(pass, inferredSiteLookup(res.InferredMap)) {
+				if shouldReport(d) {
+					pass.Report(d)
+				}
+			}
+		}
+		return nil, nil
+	}
+
+	// Collect the structured diagnostics (which contain the complete nil flows and the
+	// fingerprints) that should be reported.
+	var diagnostics []diagnostic.Diagnostic
+	for _, d := range res.Diagnostics {
+		if shouldReport(d.Diagnostic) {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	if writer != nil {
+		// Record the diagnostics to the baseline instead of reporting them.
+		for _, d := range diagnostics {
+			writer.add(pass.Pkg.Path(), pass.Fset.Position(d.Pos), d)
+		}
+		if err := writer.flush(); err != nil {
+			return nil, err
+		}
+		diagnostics = nil
+	}
+	if base != nil {
+		var fixed []baselineEntry
+		diagnostics, fixed = base.filter(pass.Pkg.Path(), diagnostics)
+		if len(fixed) > 0 {
+			fmt.Fprint(os.Stderr, pruneSummary(pass.Pkg.Path(), fixed))
+		}
+	}
+	if diff != nil {
+		var touched []diagnostic.Diagnostic
+		for _, d := range diagnostics {
+			if diff.touches(pass.Fset.Position(d.Pos), d) {
+				touched = append(touched, d)
+			}
+		}
+		diagnostics = touched
+	}
+
+	// Override the report function to add error filtering logic.
+	conf := pass.ResultOf[config.Analyzer].(*config.Config)
+	pass.Report = reportOnly(pass.Report, diagnostics, conf.PrettyPrint)
+
 	// Delegate the real analysis run to the original nilaway analyzer.
-	return nilaway.Analyzer.Run(pass)
+	result, err := nilaway.Analyzer.Run(pass)
+	if err != nil || sarif == nil {
+		return result, err
+	}
+
+	// Record the structured diagnostics for SARIF output.
+	for _, d := range diagnostics {
+		sarif.add(pass.Fset.Position(d.Pos), d)
+	}
+	if err := sarif.flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reportOnly wraps the report function such that only the given diagnostics are reported. Note
+// that multiple diagnostics might be reported at the same position (where only some of them may
+// survive the filters), so we key them by the messages as well (which contain the IDs) and count
+// them. prettyPrint indicates whether the messages are pretty-printed when reported.
+func reportOnly(report func(analysis.Diagnostic), diagnostics []diagnostic.Diagnostic, prettyPrint bool) func(analysis.Diagnostic) {
+	type reportKey struct {
+		pos     token.Pos
+		message string
+	}
+	remaining := make(map[reportKey]int)
+	for _, d := range diagnostics {
+		message := d.Message
+		if prettyPrint {
+			message = util.PrettyPrintErrorMessage(message)
+		}
+		remaining[reportKey{pos: d.Pos, message: message}]++
+	}
+	return func(d analysis.Diagnostic) {
+		key := reportKey{pos: d.Pos, message: d.Message}
+		if remaining[key] > 0 {
+			remaining[key]--
+			report(d)
+		}
+	}
 }
 
 // parseFilePrefixes parses the comma-separated list of file prefixes, converts them to absolute
@@ -124,7 +283,9 @@ func main() {
 	//
 	config.Analyzer.Flags.VisitAll(func(f *flag.Flag) { flag.Var(f.Value, f.Name, f.Usage) })
 
-	// Add two more flags to the driver for error suppression since singlechecker does not support it.
+	// Add more flags to the driver for error suppression (since singlechecker does not support it),
+	// SARIF output, baselines, diff-aware mode, the query mode for explaining the sites and the
+	// mode for emitting the inferred annotations.
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to get working directory: %v\n", err)
@@ -132,6 +293,47 @@ func main() {
 	}
 	flag.StringVar(&_includeErrorsInFiles, "include-errors-in-files", wd, "A comma-separated list of file prefixes to report errors, default is current working directory.")
 	flag.StringVar(&_excludeErrorsInFiles, "exclude-errors-in-files", "", "A comma-separated list of file prefixes to exclude from error reporting. This takes precedence over include-errors-in-files.")
+	flag.StringVar(&_sarif, "sarif", "", "Write the diagnostics (including the complete nil flows) in SARIF 2.1.0 format to the given file, in addition to the normal output.")
+	_sarifRecorder = sync.OnceValues(func() (*sarifRecorder, error) {
+		if _sarif == "" {
+			return nil, nil
+		}
+		return newSARIFRecorder(_sarif, wd)
+	})
+	flag.StringVar(&_writeBaseline, "write-baseline", "", "Write the fingerprints of all diagnostics to the given baseline file instead of reporting them.")
+	_baselineWriter = sync.OnceValues(func() (*baselineWriter, error) {
+		if _writeBaseline == "" {
+			return nil, nil
+		}
+		return newBaselineWriter(_writeBaseline, wd)
+	})
+	flag.StringVar(&_baselinePath, "baseline", "", "Only report the diagnostics that are not in the given baseline file (see -write-baseline), and print the baseline entries that are fixed.")
+	_baseline = sync.OnceValues(func() (*baseline, error) {
+		if _baselinePath == "" {
+			return nil, nil
+		}
+		return loadBaseline(_baselinePath)
+	})
+	flag.StringVar(&_patch, "patch", "", "Only report the diagnostics whose nil flows touch the lines changed in the given unified diff (patch) file (\"-\" for stdin), where the file names are relative to the current working directory.")
+	_diffFilter = sync.OnceValues(func() (*diffFilter, error) {
+		if _patch == "" {
+			return nil, nil
+		}
+		return loadDiff(_patch, wd)
+	})
+	flag.StringVar(&_explain, "explain", "", "Explain why the given site is nilable or nonnil instead of reporting errors, where the site is one of \"<pkg>.<Func> result|param <N>\", \"<pkg>.<Type>.<Method> result|param <N>\", \"<pkg>.<Type>.<field>\" and \"<pkg>.<Var>\".")
+	_explainer = sync.OnceValues(func() (*explainer, error) {
+		if _explain == "" {
+			return nil, nil
+		}
+		query, err := parseExplainQuery(_explain)
+		if err != nil {
+			return nil, err
+		}
+		return &explainer{w: os.Stdout, query: query}, nil
+	})
+
+	flag.BoolVar(&_emitAnnotations, "emit-annotations", false, "Report the inferred nilabilities of the exported functions, methods and struct fields as nilability annotations instead of reporting errors, where -fix writes them back to the doc comments.")
 
 	singlechecker.Main(Analyzer)
 }
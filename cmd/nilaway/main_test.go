@@ -0,0 +1,67 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/diagnostic"
+	"go.uber.org/nilaway/util"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestReportOnly(t *testing.T) {
+	t.Parallel()
+
+	diag := func(pos token.Pos, msg string) analysis.Diagnostic {
+		return analysis.Diagnostic{Pos: pos, Message: msg}
+	}
+	var reported []analysis.Diagnostic
+	report := reportOnly(func(d analysis.Diagnostic) { reported = append(reported, d) }, []diagnostic.Diagnostic{
+		// Only the second diagnostic at the position survived the filters.
+		{Diagnostic: diag(1, "second [ID: bbb]")},
+		{Diagnostic: diag(2, "dup [ID: ccc]")},
+		{Diagnostic: diag(2, "dup [ID: ccc]")},
+	}, false /* prettyPrint */)
+	for _, d := range []analysis.Diagnostic{
+		diag(1, "first [ID: aaa]"),
+		diag(1, "second [ID: bbb]"),
+		diag(2, "dup [ID: ccc]"),
+		diag(2, "dup [ID: ccc]"),
+		diag(2, "dup [ID: ccc]"),
+		diag(3, "other [ID: ddd]"),
+	} {
+		report(d)
+	}
+	require.Equal(t, []analysis.Diagnostic{
+		diag(1, "second [ID: bbb]"),
+		diag(2, "dup [ID: ccc]"),
+		diag(2, "dup [ID: ccc]"),
+	}, reported)
+}
+
+func TestReportOnly_PrettyPrint(t *testing.T) {
+	t.Parallel()
+
+	d := analysis.Diagnostic{Pos: 1, Message: "`x` dereferenced [ID: aaa]"}
+	var reported []analysis.Diagnostic
+	report := reportOnly(func(d analysis.Diagnostic) { reported = append(reported, d) },
+		[]diagnostic.Diagnostic{{Diagnostic: d}}, true /* prettyPrint */)
+	pretty := analysis.Diagnostic{Pos: 1, Message: util.PrettyPrintErrorMessage(d.Message)}
+	report(pretty)
+	require.Equal(t, []analysis.Diagnostic{pretty}, reported)
+}
@@ -0,0 +1,275 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/nilaway/diagnostic"
+)
+
+// The following structs implement the subset of the [SARIF 2.1.0] specification that NilAway
+// needs for reporting the nil flows.
+//
+// [SARIF 2.1.0]: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	_sarifVersion = "2.1.0"
+	_sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// _sarifRuleID is the ID of the only rule NilAway reports.
+	_sarifRuleID = "nilaway"
+	// _sarifSrcRoot is the URI base ID for the files under the current working directory.
+	_sarifSrcRoot = "%SRCROOT%"
+	// _sarifFingerprintKey is the key of the NilAway fingerprints in the partial fingerprints.
+	_sarifFingerprintKey = "nilawayFingerprint/v1"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	CodeFlows        []sarifCodeFlow `json:"codeFlows,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	// PartialFingerprints maps the fingerprint kinds to the stable IDs of the results, which are
+	// used by SARIF consumers to match the same results across runs.
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          *sarifProperties  `json:"properties,omitempty"`
+}
+
+type sarifProperties struct {
+	Category string `json:"category,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               *int                   `json:"id,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifCodeFlow struct {
+	ThreadFlows []sarifThreadFlow `json:"threadFlows"`
+}
+
+type sarifThreadFlow struct {
+	Locations []sarifThreadFlowLocation `json:"locations"`
+}
+
+type sarifThreadFlowLocation struct {
+	Location sarifLocation `json:"location"`
+}
+
+// sarifRecorder collects the SARIF results from all analyzed packages and writes the complete
+// SARIF log to the output file. It is safe for concurrent use.
+type sarifRecorder struct {
+	mu sync.Mutex
+	// path is the path of the output file.
+	path string
+	// cwd is the current working directory, files under which are reported relative to it.
+	cwd     string
+	results []sarifResult
+}
+
+// newSARIFRecorder creates a recorder writing to the given path, and writes an empty SARIF log to
+// the path immediately such that the output file is valid even if there are no diagnostics.
+func newSARIFRecorder(path, cwd string) (*sarifRecorder, error) {
+	r := &sarifRecorder{path: path, cwd: cwd}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// add converts the diagnostic reported at the given position to a SARIF result and records it.
+func (r *sarifRecorder) add(position token.Position, d diagnostic.Diagnostic) {
+	result := sarifResult{
+		RuleID:    _sarifRuleID,
+		Level:     "error",
+		Message:   sarifMessage{Text: d.Message},
+		Locations: []sarifLocation{r.location(position, "")},
+	}
+	if d.Fingerprint != "" {
+		result.PartialFingerprints = map[string]string{_sarifFingerprintKey: d.Fingerprint}
+	}
+	if d.Category != "" {
+		result.Properties = &sarifProperties{Category: d.Category}
+	}
+
+	// Each node in the nil flow (nil path first, then the nonnil path) becomes a step in the
+	// thread flow, located at its consumer position (or its producer position if it comes from an
+	// annotation).
+	var steps []sarifThreadFlowLocation
+	for _, path := range [...][]diagnostic.FlowNode{d.Flow.NilPath, d.Flow.NonNilPath} {
+		for _, n := range path {
+			steps = append(steps, sarifThreadFlowLocation{Location: r.location(n.Position(), n.String())})
+		}
+	}
+	if len(steps) > 0 {
+		result.CodeFlows = []sarifCodeFlow{{ThreadFlows: []sarifThreadFlow{{Locations: steps}}}}
+	}
+
+	// The similar conflicts (i.e., conflicts sharing the same nil path) are reported as related
+	// locations pointing to their dereference points.
+	for i, f := range d.SimilarFlows {
+		if len(f.NonNilPath) == 0 {
+			continue
+		}
+		id := i + 1
+		last := f.NonNilPath[len(f.NonNilPath)-1]
+		loc := r.location(last.Position(), "Same nil source could also cause potential nil panic here: "+last.String())
+		loc.ID = &id
+		result.RelatedLocations = append(result.RelatedLocations, loc)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+}
+
+// location returns the SARIF location for the given position and (optional) message.
+func (r *sarifRecorder) location(position token.Position, msg string) sarifLocation {
+	var loc sarifLocation
+	if msg != "" {
+		loc.Message = &sarifMessage{Text: msg}
+	}
+	if !position.IsValid() {
+		return loc
+	}
+
+	filename := position.Filename
+	artifact := sarifArtifactLocation{URI: filepath.ToSlash(filename), URIBaseID: _sarifSrcRoot}
+	if filepath.IsAbs(filename) {
+		if rel, err := filepath.Rel(r.cwd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			artifact.URI = filepath.ToSlash(rel)
+		} else {
+			artifact = sarifArtifactLocation{URI: "file://" + filepath.ToSlash(filename)}
+		}
+	}
+	loc.PhysicalLocation = &sarifPhysicalLocation{
+		ArtifactLocation: artifact,
+		Region:           &sarifRegion{StartLine: position.Line, StartColumn: position.Column},
+	}
+	return loc
+}
+
+// flush writes the complete SARIF log containing all results recorded so far to the output file.
+// Since the singlechecker exits the process directly after the analysis, this has to be called
+// whenever new results are added to keep the output file up-to-date.
+func (r *sarifRecorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Packages are analyzed concurrently, so we sort the results for deterministic output.
+	results := slices.Clone(r.results)
+	slices.SortStableFunc(results, func(a, b sarifResult) int {
+		la, lb := a.Locations[0].PhysicalLocation, b.Locations[0].PhysicalLocation
+		if la == nil || lb == nil {
+			return cmp.Compare(a.Message.Text, b.Message.Text)
+		}
+		if n := cmp.Compare(la.ArtifactLocation.URI, lb.ArtifactLocation.URI); n != 0 {
+			return n
+		}
+		if n := cmp.Compare(la.Region.StartLine, lb.Region.StartLine); n != 0 {
+			return n
+		}
+		return cmp.Compare(la.Region.StartColumn, lb.Region.StartColumn)
+	})
+	if results == nil {
+		// SARIF requires the results to be an array instead of null.
+		results = []sarifResult{}
+	}
+
+	log := sarifLog{
+		Version: _sarifVersion,
+		Schema:  _sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           _sarifRuleID,
+				InformationURI: "https://github.com/uber-go/nilaway",
+				Rules: []sarifRule{{
+					ID:               _sarifRuleID,
+					ShortDescription: sarifMessage{Text: "Potential nil panic"},
+				}},
+			}},
+			OriginalURIBaseIDs: map[string]sarifArtifactLocation{
+				_sarifSrcRoot: {URI: "file://" + filepath.ToSlash(r.cwd) + "/"},
+			},
+			Results: results,
+		}},
+	}
+
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal SARIF log: %w", err)
+	}
+	if err := os.WriteFile(r.path, out, 0o644); err != nil {
+		return fmt.Errorf("write SARIF log to %q: %w", r.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/diagnostic"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestSARIFRecorder_Empty(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	_, err := newSARIFRecorder(path, "/src")
+	require.NoError(t, err)
+
+	log := readSARIF(t, path)
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.NotNil(t, log.Runs[0].Results)
+	require.Empty(t, log.Runs[0].Results)
+}
+
+func TestSARIFRecorder_Flow(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.sarif")
+	r, err := newSARIFRecorder(path, "/src")
+	require.NoError(t, err)
+
+	pos := func(file string, line int) token.Position {
+		return token.Position{Filename: file, Line: line, Column: 2, Offset: 1}
+	}
+	r.add(pos("/src/pkg/b.go", 20), diagnostic.Diagnostic{
+		Diagnostic: analysis.Diagnostic{Message: "second"},
+	})
+	r.add(pos("/src/pkg/a.go", 10), diagnostic.Diagnostic{
+		Diagnostic:  analysis.Diagnostic{Message: "first", Category: "dereference"},
+		Fingerprint: "0123456789abcdef",
+		Flow: diagnostic.Flow{
+			NilPath: []diagnostic.FlowNode{
+				{ConsumerPosition: pos("pkg/a.go", 5), Producer: "literal `nil`", Consumer: "returned from `foo()`"},
+			},
+			NonNilPath: []diagnostic.FlowNode{
+				// Steps from annotations only carry the positions of the annotations.
+				{ProducerPosition: pos("stubs.yaml", 3), Producer: "result 0 of `bar()`", Consumer: "annotated as nonnil"},
+				{ConsumerPosition: pos("pkg/a.go", 10), Producer: "result 0 of `foo()`", Consumer: "dereferenced"},
+			},
+		},
+		SimilarFlows: []diagnostic.Flow{{
+			NonNilPath: []diagnostic.FlowNode{
+				{ConsumerPosition: pos("/other/c.go", 30), Consumer: "dereferenced"},
+			},
+		}},
+	})
+	require.NoError(t, r.flush())
+
+	log := readSARIF(t, path)
+	require.Len(t, log.Runs, 1)
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+
+	// Results should be sorted by their locations.
+	first, second := results[0], results[1]
+	require.Equal(t, "second", second.Message.Text)
+	require.Empty(t, second.CodeFlows)
+	require.Equal(t, "first", first.Message.Text)
+	require.Equal(t, "pkg/a.go", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, _sarifSrcRoot, first.Locations[0].PhysicalLocation.ArtifactLocation.URIBaseID)
+	require.Equal(t, 10, first.Locations[0].PhysicalLocation.Region.StartLine)
+	require.Equal(t, "dereference", first.Properties.Category)
+	require.Nil(t, second.Properties)
+	require.Equal(t, map[string]string{_sarifFingerprintKey: "0123456789abcdef"}, first.PartialFingerprints)
+	require.Nil(t, second.PartialFingerprints)
+
+	// The nil path and the nonnil path should be joined in order.
+	require.Len(t, first.CodeFlows, 1)
+	require.Len(t, first.CodeFlows[0].ThreadFlows, 1)
+	steps := first.CodeFlows[0].ThreadFlows[0].Locations
+	require.Len(t, steps, 3)
+	require.Equal(t, "literal `nil` returned from `foo()`", steps[0].Location.Message.Text)
+	require.Equal(t, 5, steps[0].Location.PhysicalLocation.Region.StartLine)
+	require.Equal(t, "stubs.yaml", steps[1].Location.PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, 3, steps[1].Location.PhysicalLocation.Region.StartLine)
+	require.Equal(t, "result 0 of `foo()` dereferenced", steps[2].Location.Message.Text)
+	require.Equal(t, 10, steps[2].Location.PhysicalLocation.Region.StartLine)
+
+	// Files outside the working directory should be referred to by absolute URIs.
+	require.Len(t, first.RelatedLocations, 1)
+	related := first.RelatedLocations[0]
+	require.NotNil(t, related.ID)
+	require.Equal(t, "file:///other/c.go", related.PhysicalLocation.ArtifactLocation.URI)
+	require.Empty(t, related.PhysicalLocation.ArtifactLocation.URIBaseID)
+}
+
+func readSARIF(t *testing.T, path string) sarifLog {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(content, &log))
+	return log
+}
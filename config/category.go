@@ -0,0 +1,83 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// The categories of the diagnostics, which are determined by the kind of the final consumer in the
+// nil flows (i.e., where the nil value is finally consumed). They are set as the Category of the
+// reported diagnostics, and can be enabled or disabled via the EnableCategoriesFlag and
+// DisableCategoriesFlag. The new categories must also be added to Categories.
+const (
+	// CategoryDereference is for pointer dereferences, and the other uses where nil is never
+	// allowed.
+	CategoryDereference = "dereference"
+	// CategoryFieldAccess is for field accesses and method calls.
+	CategoryFieldAccess = "field-access"
+	// CategoryMapAccess is for map reads.
+	CategoryMapAccess = "map-access"
+	// CategoryMapWrite is for map writes.
+	CategoryMapWrite = "map-write"
+	// CategorySliceAccess is for slice indexing and slicing.
+	CategorySliceAccess = "slice-access"
+	// CategoryReceiver is for uses as receivers of method calls.
+	CategoryReceiver = "receiver"
+	// CategoryArgument is for uses as (fields of) arguments of function calls.
+	CategoryArgument = "argument"
+	// CategoryReturn is for uses as (fields of) results of functions.
+	CategoryReturn = "return"
+	// CategoryErrorReturn is for the violations of the error return contracts.
+	CategoryErrorReturn = "error-return"
+	// CategoryFieldAssign is for assignments to fields.
+	CategoryFieldAssign = "field-assign"
+	// CategoryGlobalVar is for assignments to global variables.
+	CategoryGlobalVar = "global-var"
+	// CategoryAssign is for the flows into the other nonnil sites.
+	CategoryAssign = "assign"
+	// CategoryDeepAssign is for assignments to the elements of deeply nonnil types.
+	CategoryDeepAssign = "deep-assign"
+	// CategoryInterfaceResult is for the violations of covariance of interface method results.
+	CategoryInterfaceResult = "interface-result"
+	// CategoryInterfaceParam is for the violations of contravariance of interface method parameters.
+	CategoryInterfaceParam = "interface-param"
+	// CategoryOther is for all other consumers.
+	CategoryOther = "other"
+	// CategoryAnnotation is for the malformed or ineffective nilability annotations, which are
+	// reported by the annotation linter (if enabled) instead of from the nil flows.
+	CategoryAnnotation = "annotation"
+	// CategoryContract is for the malformed or violated handwritten function contracts.
+	CategoryContract = "contract"
+)
+
+// Categories is the list of all diagnostic categories, which the categories passed to the
+// EnableCategoriesFlag and DisableCategoriesFlag are checked against.
+var Categories = []string{
+	CategoryDereference,
+	CategoryFieldAccess,
+	CategoryMapAccess,
+	CategoryMapWrite,
+	CategorySliceAccess,
+	CategoryReceiver,
+	CategoryArgument,
+	CategoryReturn,
+	CategoryErrorReturn,
+	CategoryFieldAssign,
+	CategoryGlobalVar,
+	CategoryAssign,
+	CategoryDeepAssign,
+	CategoryInterfaceResult,
+	CategoryInterfaceParam,
+	CategoryOther,
+	CategoryAnnotation,
+	CategoryContract,
+}
@@ -17,9 +17,11 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"go/ast"
 	"go/types"
 	"reflect"
+	"slices"
 	"strings"
 
 	"go.uber.org/nilaway/util/asthelper"
@@ -32,20 +34,57 @@ type Config struct {
 	PrettyPrint bool
 	// GroupErrorMessages indicates whether similar error messages should be grouped.
 	GroupErrorMessages bool
+	// ConciseMessages indicates whether the error messages should only contain the source and the
+	// dereference point of the nil flows, leaving the complete flows to the related information.
+	ConciseMessages bool
+	// CheckIgnoreDirectives indicates whether the `//nilaway:ignore` directives that are unused or
+	// lack a reason should be reported.
+	CheckIgnoreDirectives bool
+	// LintAnnotations indicates whether the nilability annotations that are malformed or have no
+	// effect (e.g., annotating unknown names) should be reported.
+	LintAnnotations bool
+	// DumpInferenceGraph is the directory to dump the implication graph built by the inference
+	// engine for each analyzed package (in both DOT and JSON formats) for debugging purposes. No
+	// graphs are dumped if it is empty.
+	DumpInferenceGraph string
+	// StubFiles are the paths to the external stub files that declare the nilability annotations
+	// for the sites in packages whose source code cannot be annotated (e.g., third-party or
+	// standard library packages). See annotation.LoadStubs for the format.
+	StubFiles []string
+	// StdlibModel indicates whether the bundled nilability model of the Go standard library should
+	// be used, where the stub files take precedence over it.
+	StdlibModel bool
 	// ExperimentalStructInitEnable indicates whether experimental struct initialization is enabled.
 	ExperimentalStructInitEnable bool
 	// ExperimentalAnonymousFuncEnable indicates whether experimental anonymous function support is enabled.
 	ExperimentalAnonymousFuncEnable bool
+	// ExperimentalMultiContractInferenceEnable indicates whether the contracts are inferred for the
+	// functions with multiple parameters or results (or boolean results), instead of only the ones
+	// with a single parameter and a single result.
+	ExperimentalMultiContractInferenceEnable bool
 
 	// includePkgs is the list of packages to analyze.
 	includePkgs []string
 	// excludePkgs is the list of packages to exclude from analysis. Exclude list takes
 	// precedence over the include list.
 	excludePkgs []string
+	// enableCategories is the list of diagnostic categories to report, an empty list means all
+	// categories are reported.
+	enableCategories []string
+	// disableCategories is the list of diagnostic categories to not report. Disable list takes
+	// precedence over the enable list.
+	disableCategories []string
 	// excludeFileDocStrings is the list of doc strings that, if they appear in the file doc
 	// string, will cause the file to be excluded from analysis. Examples include "@generated" and
 	// "Code generated by".
 	excludeFileDocStrings []string
+	// defaultNilableTypes is the list of fully-qualified named types (e.g., "example.com/db.Tx")
+	// whose sites are nilable unless annotated otherwise.
+	defaultNilableTypes []string
+	// defaultNonnilTypes is the list of fully-qualified named types (e.g., "go.uber.org/zap.Logger")
+	// whose sites are nonnil unless annotated otherwise. The default nilable list takes precedence
+	// over this list.
+	defaultNonnilTypes []string
 }
 
 // IsPkgInScope returns true iff the passed package is in scope for analysis, i.e., it is in the
@@ -71,6 +110,16 @@ func (c *Config) IsPkgInScope(pkg *types.Package) bool {
 	return false
 }
 
+// IsCategoryEnabled returns true iff the diagnostics of the given category should be reported, i.e.,
+// the category is in the configured enable list (or the enable list is empty) but not in the
+// disable list.
+func (c *Config) IsCategoryEnabled(category string) bool {
+	if slices.Contains(c.disableCategories, category) {
+		return false
+	}
+	return len(c.enableCategories) == 0 || slices.Contains(c.enableCategories, category)
+}
+
 // IsFileInScope returns true iff we should analyze the file. It checks the docstring of the file
 // and returns false if any of the strings in ExcludeFileDocStrings appear in the file docstring.
 func (c *Config) IsFileInScope(file *ast.File) bool {
@@ -95,6 +144,43 @@ func (c *Config) IsFileInScope(file *ast.File) bool {
 	return true
 }
 
+// DefaultNilability returns the configured default nilability of the given type, and false if the
+// type is not configured. A configured named type also covers the pointers to it, and a configured
+// generic type covers all its instantiations (while an instantiation, e.g.,
+// "example.com/opt.Optional[int]", only covers itself).
+func (c *Config) DefaultNilability(t types.Type) (nilable bool, ok bool) {
+	if len(c.defaultNilableTypes) == 0 && len(c.defaultNonnilTypes) == 0 {
+		return false, false
+	}
+
+	prefix := ""
+	if ptr, isPtr := t.(*types.Pointer); isPtr {
+		t, prefix = ptr.Elem(), "*"
+	}
+	named, isNamed := t.(*types.Named)
+	if !isNamed {
+		return false, false
+	}
+	obj := named.Origin().Obj()
+	name := obj.Name()
+	if obj.Pkg() != nil {
+		name = obj.Pkg().Path() + "." + name
+	}
+	// The type string contains the type arguments of the instantiations, if any.
+	names := []string{name, prefix + name, types.TypeString(named, nil), prefix + types.TypeString(named, nil)}
+
+	matches := func(list []string) bool {
+		return slices.ContainsFunc(list, func(s string) bool { return slices.Contains(names, s) })
+	}
+	switch {
+	case matches(c.defaultNilableTypes):
+		return true, true
+	case matches(c.defaultNonnilTypes):
+		return false, true
+	}
+	return false, false
+}
+
 const _doc = `nilaway_config analyzer is responsible to take configurations (flags) for NilAway execution.
 It does not run any analysis and is only meant to be used as a dependency for the sub-analyzers of 
 NilAway to share the same configurations. 
@@ -125,16 +211,40 @@ const (
 	PrettyPrintFlag = "pretty-print"
 	// GroupErrorMessagesFlag is the flag for grouping similar error messages.
 	GroupErrorMessagesFlag = "group-error-messages"
+	// ConciseMessagesFlag is the flag for only printing the source and the dereference point in
+	// the error messages.
+	ConciseMessagesFlag = "concise-messages"
+	// CheckIgnoreDirectivesFlag is the flag for reporting unused or reason-less ignore directives.
+	CheckIgnoreDirectivesFlag = "check-ignore-directives"
+	// LintAnnotationsFlag is the flag for reporting malformed or dangling nilability annotations.
+	LintAnnotationsFlag = "lint-annotations"
 	// IncludePkgsFlag is the flag name for include package prefixes.
 	IncludePkgsFlag = "include-pkgs"
 	// ExcludePkgsFlag is the flag name for exclude package prefixes.
 	ExcludePkgsFlag = "exclude-pkgs"
+	// EnableCategoriesFlag is the flag name for the diagnostic categories to report.
+	EnableCategoriesFlag = "enable-categories"
+	// DisableCategoriesFlag is the flag name for the diagnostic categories to not report.
+	DisableCategoriesFlag = "disable-categories"
 	// ExcludeFileDocStringsFlag is the flag name for the docstrings that exclude files from analysis.
 	ExcludeFileDocStringsFlag = "exclude-file-docstrings"
+	// DefaultNilableTypesFlag is the flag name for the named types that are nilable by default.
+	DefaultNilableTypesFlag = "default-nilable-types"
+	// DefaultNonnilTypesFlag is the flag name for the named types that are nonnil by default.
+	DefaultNonnilTypesFlag = "default-nonnil-types"
+	// DumpInferenceGraphFlag is the flag name for the directory to dump the inference graphs.
+	DumpInferenceGraphFlag = "dump-inference-graph"
+	// StubFilesFlag is the flag name for the external annotation stub files.
+	StubFilesFlag = "stub-files"
+	// StdlibModelFlag is the flag name for using the bundled standard library model.
+	StdlibModelFlag = "stdlib-model"
 	// ExperimentalStructInitEnableFlag is the flag name for the experimental struct init support.
 	ExperimentalStructInitEnableFlag = "experimental-struct-init"
 	// ExperimentalAnonymousFunctionFlag is the flag name for the experimental anonymous function support.
 	ExperimentalAnonymousFunctionFlag = "experimental-anonymous-function"
+	// ExperimentalMultiContractInferenceFlag is the flag name for the experimental contract
+	// inference for functions with multiple parameters or results.
+	ExperimentalMultiContractInferenceFlag = "experimental-multi-contract-inference"
 )
 
 // newFlagSet returns a flag set to be used in the nilaway config analyzer.
@@ -145,11 +255,22 @@ func newFlagSet() flag.FlagSet {
 	// Instead, we will use the flags through the analyzer's Flags field later.
 	_ = fs.Bool(PrettyPrintFlag, true, "Pretty print the error messages")
 	_ = fs.Bool(GroupErrorMessagesFlag, true, "Group similar error messages")
+	_ = fs.Bool(ConciseMessagesFlag, false, "Only print the source and the dereference point of the nil flows in the error messages, the complete flows are always available as related information")
+	_ = fs.Bool(CheckIgnoreDirectivesFlag, false, "Report //nilaway:ignore directives that are unused or lack a reason")
+	_ = fs.Bool(LintAnnotationsFlag, false, "Report nilability annotations that are malformed or have no effect (e.g., annotating unknown names)")
 	_ = fs.String(IncludePkgsFlag, "", "Comma-separated list of packages to analyze")
 	_ = fs.String(ExcludePkgsFlag, "", "Comma-separated list of packages to exclude from analysis")
+	_ = fs.String(EnableCategoriesFlag, "", "Comma-separated list of diagnostic categories to report (default all)")
+	_ = fs.String(DisableCategoriesFlag, "", "Comma-separated list of diagnostic categories to not report")
 	_ = fs.String(ExcludeFileDocStringsFlag, "", "Comma-separated list of docstrings to exclude from analysis")
+	_ = fs.String(DefaultNilableTypesFlag, "", "Comma-separated list of fully-qualified named types (e.g., example.com/db.Tx) whose sites are nilable unless annotated otherwise")
+	_ = fs.String(DefaultNonnilTypesFlag, "", "Comma-separated list of fully-qualified named types (e.g., go.uber.org/zap.Logger) whose sites are nonnil unless annotated otherwise")
+	_ = fs.String(DumpInferenceGraphFlag, "", "Directory to dump the inference implication graph of each package in DOT and JSON formats (for debugging)")
+	_ = fs.String(StubFilesFlag, "", "Comma-separated list of YAML or JSON stub files declaring the nilability of sites in other (e.g., third-party) packages")
+	_ = fs.Bool(StdlibModelFlag, true, "Use the bundled nilability model of the Go standard library")
 	_ = fs.Bool(ExperimentalStructInitEnableFlag, false, "Whether to enable experimental struct initialization support")
 	_ = fs.Bool(ExperimentalAnonymousFunctionFlag, false, "Whether to enable experimental anonymous function support")
+	_ = fs.Bool(ExperimentalMultiContractInferenceFlag, false, "Whether to enable experimental contract inference for functions with multiple parameters or results")
 
 	return *fs
 }
@@ -159,6 +280,7 @@ func run(pass *analysis.Pass) (any, error) {
 	conf := &Config{
 		PrettyPrint:        true,
 		GroupErrorMessages: true,
+		StdlibModel:        true,
 		// If the user does not provide an include list, we give an empty package prefix to catch
 		// all packages.
 		includePkgs: []string{""},
@@ -171,21 +293,77 @@ func run(pass *analysis.Pass) (any, error) {
 	if groupErrorMessages, ok := pass.Analyzer.Flags.Lookup(GroupErrorMessagesFlag).Value.(flag.Getter).Get().(bool); ok {
 		conf.GroupErrorMessages = groupErrorMessages
 	}
+	if conciseMessages, ok := pass.Analyzer.Flags.Lookup(ConciseMessagesFlag).Value.(flag.Getter).Get().(bool); ok {
+		conf.ConciseMessages = conciseMessages
+	}
+	if checkIgnoreDirectives, ok := pass.Analyzer.Flags.Lookup(CheckIgnoreDirectivesFlag).Value.(flag.Getter).Get().(bool); ok {
+		conf.CheckIgnoreDirectives = checkIgnoreDirectives
+	}
+	if lintAnnotations, ok := pass.Analyzer.Flags.Lookup(LintAnnotationsFlag).Value.(flag.Getter).Get().(bool); ok {
+		conf.LintAnnotations = lintAnnotations
+	}
 	if enableStructInit, ok := pass.Analyzer.Flags.Lookup(ExperimentalStructInitEnableFlag).Value.(flag.Getter).Get().(bool); ok {
 		conf.ExperimentalStructInitEnable = enableStructInit
 	}
 	if enableAnonymousFunc, ok := pass.Analyzer.Flags.Lookup(ExperimentalAnonymousFunctionFlag).Value.(flag.Getter).Get().(bool); ok {
 		conf.ExperimentalAnonymousFuncEnable = enableAnonymousFunc
 	}
+	if enableMultiContractInference, ok := pass.Analyzer.Flags.Lookup(ExperimentalMultiContractInferenceFlag).Value.(flag.Getter).Get().(bool); ok {
+		conf.ExperimentalMultiContractInferenceEnable = enableMultiContractInference
+	}
 	if include, ok := pass.Analyzer.Flags.Lookup(IncludePkgsFlag).Value.(flag.Getter).Get().(string); ok && include != "" {
 		conf.includePkgs = strings.Split(include, ",")
 	}
 	if exclude, ok := pass.Analyzer.Flags.Lookup(ExcludePkgsFlag).Value.(flag.Getter).Get().(string); ok && exclude != "" {
 		conf.excludePkgs = strings.Split(exclude, ",")
 	}
+	if enable, ok := pass.Analyzer.Flags.Lookup(EnableCategoriesFlag).Value.(flag.Getter).Get().(string); ok && enable != "" {
+		categories, err := parseCategories(EnableCategoriesFlag, enable)
+		if err != nil {
+			return nil, err
+		}
+		conf.enableCategories = categories
+	}
+	if disable, ok := pass.Analyzer.Flags.Lookup(DisableCategoriesFlag).Value.(flag.Getter).Get().(string); ok && disable != "" {
+		categories, err := parseCategories(DisableCategoriesFlag, disable)
+		if err != nil {
+			return nil, err
+		}
+		conf.disableCategories = categories
+	}
+	if nilableTypes, ok := pass.Analyzer.Flags.Lookup(DefaultNilableTypesFlag).Value.(flag.Getter).Get().(string); ok && nilableTypes != "" {
+		conf.defaultNilableTypes = strings.Split(nilableTypes, ",")
+	}
+	if nonnilTypes, ok := pass.Analyzer.Flags.Lookup(DefaultNonnilTypesFlag).Value.(flag.Getter).Get().(string); ok && nonnilTypes != "" {
+		conf.defaultNonnilTypes = strings.Split(nonnilTypes, ",")
+	}
+	if dumpInferenceGraph, ok := pass.Analyzer.Flags.Lookup(DumpInferenceGraphFlag).Value.(flag.Getter).Get().(string); ok {
+		conf.DumpInferenceGraph = dumpInferenceGraph
+	}
+	if stubFiles, ok := pass.Analyzer.Flags.Lookup(StubFilesFlag).Value.(flag.Getter).Get().(string); ok && stubFiles != "" {
+		conf.StubFiles = strings.Split(stubFiles, ",")
+	}
+	if stdlibModel, ok := pass.Analyzer.Flags.Lookup(StdlibModelFlag).Value.(flag.Getter).Get().(bool); ok {
+		conf.StdlibModel = stdlibModel
+	}
 	if docstrings, ok := pass.Analyzer.Flags.Lookup(ExcludeFileDocStringsFlag).Value.(flag.Getter).Get().(string); ok && docstrings != "" {
 		conf.excludeFileDocStrings = strings.Split(docstrings, ",")
 	}
 
 	return conf, nil
 }
+
+// parseCategories splits the comma-separated list of categories passed to the flag, and returns an
+// error if any of them is unknown (see Categories). Otherwise, a typo would silently suppress the
+// diagnostics of all categories in the enable list.
+func parseCategories(flagName string, value string) ([]string, error) {
+	categories := strings.Split(value, ",")
+	for i, category := range categories {
+		categories[i] = strings.TrimSpace(category)
+		if !slices.Contains(Categories, categories[i]) {
+			return nil, fmt.Errorf("unknown diagnostic category %q in -%s, expected one of %s",
+				categories[i], flagName, strings.Join(Categories, ", "))
+		}
+	}
+	return categories, nil
+}
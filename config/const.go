@@ -40,4 +40,7 @@ const NilAwayPkgPathPrefix = uberPkgPathPrefix + "/nilaway"
 const DirLevelsToPrintForTriggers = 1
 
 // DefaultNilableNamedTypes is the list of type names that we interpret as default nilable.
+//
+// Deprecated: it is not consulted by NilAway, configure the default nilable types with the
+// -default-nilable-types flag (see Config.DefaultNilability) instead.
 var DefaultNilableNamedTypes = [...]string{}
@@ -0,0 +1,72 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
+)
+
+// category returns the category (see the Category constants in package config) of the consumer.
+// Every consumer kind is mapped explicitly, such that config.CategoryOther is only for the unknown ones.
+func category(consumer annotation.Prestring) string {
+	switch consumer.(type) {
+	case annotation.PtrLoadPrestring, annotation.ConsumeTriggerTautologyPrestring:
+		return config.CategoryDereference
+	case annotation.FldAccessPrestring:
+		return config.CategoryFieldAccess
+	case annotation.MapAccessPrestring:
+		return config.CategoryMapAccess
+	case annotation.MapWrittenToPrestring:
+		return config.CategoryMapWrite
+	case annotation.SliceAccessPrestring:
+		return config.CategorySliceAccess
+	case annotation.RecvPassPrestring:
+		return config.CategoryReceiver
+	case annotation.ArgPassPrestring, annotation.ArgPassDeepPrestring, annotation.ArgFldPassPrestring:
+		return config.CategoryArgument
+	case annotation.UseAsReturnPrestring, annotation.UseAsReturnDeepPrestring, annotation.UseAsFldOfReturnPrestring:
+		return config.CategoryReturn
+	case annotation.UseAsErrorResultPrestring, annotation.UseAsNonErrorRetDependentOnErrorRetNilabilityPrestring,
+		annotation.UseAsErrorRetWithNilabilityUnknownPrestring:
+		return config.CategoryErrorReturn
+	case annotation.FldAssignPrestring, annotation.FieldAssignDeepPrestring, annotation.FldEscapePrestring:
+		return config.CategoryFieldAssign
+	case annotation.GlobalVarAssignPrestring, annotation.GlobalVarAssignDeepPrestring:
+		return config.CategoryGlobalVar
+	case annotation.TriggerIfNonNilPrestring:
+		return config.CategoryAssign
+	case annotation.TriggerIfDeepNonNilPrestring, annotation.SliceAssignPrestring, annotation.ArrayAssignPrestring, annotation.PtrAssignPrestring,
+		annotation.MapAssignPrestring, annotation.DeepAssignPrimitivePrestring, annotation.ParamAssignDeepPrestring,
+		annotation.FuncRetAssignDeepPrestring, annotation.VariadicParamAssignDeepPrestring,
+		annotation.LocalVarAssignDeepPrestring, annotation.ChanSendPrestring, annotation.ElemAssignPrestring:
+		return config.CategoryDeepAssign
+	case annotation.InterfaceResultFromImplementationPrestring:
+		return config.CategoryInterfaceResult
+	case annotation.MethodParamFromInterfacePrestring:
+		return config.CategoryInterfaceParam
+	default:
+		return config.CategoryOther
+	}
+}
+
+// category returns the category of the conflict, i.e., the category of the final consumer in the
+// nil flow.
+func (c *conflict) category() string {
+	if len(c.flow.nonnilPath) == 0 {
+		return config.CategoryOther
+	}
+	return category(c.flow.nonnilPath[len(c.flow.nonnilPath)-1].consumer)
+}
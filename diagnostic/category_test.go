@@ -0,0 +1,99 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
+)
+
+// _consumerPrestrings are the prestrings of all consumer kinds, which must be kept in sync with
+// annotation/consume_trigger.go (checked by TestCategory).
+var _consumerPrestrings = []annotation.Prestring{
+	annotation.TriggerIfNonNilPrestring{},
+	annotation.TriggerIfDeepNonNilPrestring{},
+	annotation.ConsumeTriggerTautologyPrestring{},
+	annotation.PtrLoadPrestring{},
+	annotation.MapAccessPrestring{},
+	annotation.MapWrittenToPrestring{},
+	annotation.SliceAccessPrestring{},
+	annotation.FldAccessPrestring{},
+	annotation.UseAsErrorResultPrestring{},
+	annotation.FldAssignPrestring{},
+	annotation.ArgFldPassPrestring{},
+	annotation.GlobalVarAssignPrestring{},
+	annotation.ArgPassPrestring{},
+	annotation.ArgPassDeepPrestring{},
+	annotation.RecvPassPrestring{},
+	annotation.InterfaceResultFromImplementationPrestring{},
+	annotation.MethodParamFromInterfacePrestring{},
+	annotation.UseAsReturnPrestring{},
+	annotation.UseAsReturnDeepPrestring{},
+	annotation.UseAsFldOfReturnPrestring{},
+	annotation.SliceAssignPrestring{},
+	annotation.ArrayAssignPrestring{},
+	annotation.PtrAssignPrestring{},
+	annotation.MapAssignPrestring{},
+	annotation.DeepAssignPrimitivePrestring{},
+	annotation.ParamAssignDeepPrestring{},
+	annotation.FuncRetAssignDeepPrestring{},
+	annotation.VariadicParamAssignDeepPrestring{},
+	annotation.FieldAssignDeepPrestring{},
+	annotation.GlobalVarAssignDeepPrestring{},
+	annotation.LocalVarAssignDeepPrestring{},
+	annotation.ChanSendPrestring{},
+	annotation.ElemAssignPrestring{},
+	annotation.FldEscapePrestring{},
+	annotation.UseAsNonErrorRetDependentOnErrorRetNilabilityPrestring{},
+	annotation.UseAsErrorRetWithNilabilityUnknownPrestring{},
+}
+
+func TestCategory(t *testing.T) {
+	t.Parallel()
+
+	// Collect the names of all prestring types declared for the consumers, such that the new
+	// consumer kinds cannot be left out of the list above (and hence out of the categories).
+	file, err := parser.ParseFile(token.NewFileSet(), "../annotation/consume_trigger.go", nil, parser.SkipObjectResolution)
+	require.NoError(t, err)
+	var declared []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && strings.HasSuffix(spec.Name.Name, "Prestring") {
+			if _, isStruct := spec.Type.(*ast.StructType); isStruct {
+				declared = append(declared, spec.Name.Name)
+			}
+		}
+		return true
+	})
+
+	var listed []string
+	for _, p := range _consumerPrestrings {
+		listed = append(listed, reflect.TypeOf(p).Name())
+
+		// Every consumer kind should be mapped explicitly to a known category other than
+		// CategoryOther.
+		c := category(p)
+		require.Contains(t, config.Categories, c)
+		require.NotEqual(t, config.CategoryOther, c, "%T is not mapped to any category", p)
+	}
+	require.ElementsMatch(t, declared, listed)
+}
@@ -15,10 +15,14 @@
 package diagnostic
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"go.uber.org/nilaway/config"
@@ -32,6 +36,12 @@ type conflict struct {
 	flow nilFlow
 	// similarConflicts stores other conflicts that are similar to this one.
 	similarConflicts []*conflict
+	// site is the position-independent identity of the source site of the conflict (see
+	// [Engine.fingerprint]).
+	site string
+	// fingerprint is the stable ID of the conflict, which is computed and shown in the message
+	// when generating the diagnostic.
+	fingerprint string
 }
 
 func (c *conflict) String() string {
@@ -53,8 +63,41 @@ func (c *conflict) String() string {
 			"other place(s): %s.)", len(c.similarConflicts), posString)
 	}
 
-	return fmt.Sprintf("Potential nil panic detected. Observed nil flow from "+
-		"source to dereference point: %s%s\n", c.flow.String(), similarConflictsString)
+	return fmt.Sprintf("Potential nil panic detected%s. Observed nil flow from "+
+		"source to dereference point: %s%s\n", c.idString(), c.flow.String(), similarConflictsString)
+}
+
+// conciseString returns a short representation of the conflict that only describes the source
+// and the dereference point of the nil flow. The complete flow is expected to be delivered
+// separately (e.g., as related information of the diagnostic).
+func (c *conflict) conciseString() string {
+	var nodes []node
+	nodes = append(nodes, c.flow.nilPath...)
+	nodes = append(nodes, c.flow.nonnilPath...)
+	if len(nodes) == 0 {
+		return "Potential nil panic detected."
+	}
+
+	msg := "Potential nil panic detected: " + nodes[0].reason()
+	switch {
+	case len(nodes) == 2:
+		msg += " -> " + nodes[1].reason()
+	case len(nodes) > 2:
+		msg += " -> ... -> " + nodes[len(nodes)-1].reason()
+	}
+	if len(c.similarConflicts) > 0 {
+		msg += fmt.Sprintf(" (and %d other place(s))", len(c.similarConflicts))
+	}
+	return msg + c.idString()
+}
+
+// idString returns the string for showing the fingerprint of the conflict in the messages, such
+// that users are able to refer to the conflict (e.g., in baseline files) directly.
+func (c *conflict) idString() string {
+	if c.fingerprint == "" {
+		return ""
+	}
+	return " [ID: " + c.fingerprint + "]"
 }
 
 func (c *conflict) addSimilarConflict(conflict conflict) {
@@ -67,59 +110,7 @@ func groupConflicts(allConflicts []conflict, pass *analysis.Pass, cwd string) []
 	indicesToIgnore := make(map[int]bool) // indices of conflicts to be ignored from `allConflicts`, since they are grouped with other conflicts
 
 	for i, c := range allConflicts {
-		key := pathString(c.flow.nilPath)
-
-		// Handle the case of single assertion conflict separately
-		if len(c.flow.nilPath) == 0 && len(c.flow.nonnilPath) == 1 {
-			// This is the case of single assertion conflict. Use producer position and repr from the non-nil path as
-			// the key, if present, else use the producer and consumer repr as a heuristic key to group conflicts.
-			p := c.flow.nonnilPath[0]
-			key = p.producerRepr + ";" + p.consumerRepr
-			if p.producerPosition.IsValid() {
-				key = p.producerPosition.String() + ": " + p.producerRepr
-			} else {
-				// The heuristic of using producer and consumer repr as key may not work perfectly, especially when the
-				// error messages in two different functions are exactly the same. Consider the following example:
-				// ```
-				// 	func f1() {
-				//		mp := make(map[int]*int)
-				//		_ = *mp[0] // error message: "deep read from local variable `mp` lacking guarding; dereferenced"
-				// 	}
-				//
-				// 	func f2() {
-				//		mp := make(map[int]*int)
-				//		_ = *mp[0] // error message: "deep read from local variable `mp` lacking guarding; dereferenced"
-				// 	}
-				// ```
-				// Here, the two error messages are exactly the same, but they should not be grouped together as they are
-				// from different functions. To handle such cases, we prepend the enclosing function name to the key.
-				conf := pass.ResultOf[config.Analyzer].(*config.Config)
-				for _, file := range pass.Files {
-					// `fileName` stores the complete file path relative to the current working directory
-					fileName := pass.Fset.Position(file.FileStart).Filename
-					if fn, err := filepath.Rel(cwd, fileName); err == nil {
-						fileName = fn
-					}
-					// Check if the file is in scope and the conflict position is in the same file
-					if !conf.IsFileInScope(file) || fileName != c.position.Filename {
-						continue
-					}
-					for _, decl := range file.Decls {
-						// Check if the conflict position falls within the function's position range. If so, update the key to
-						// include the function name, and end the traversal.
-						if fd, ok := decl.(*ast.FuncDecl); ok {
-							functionStart := pass.Fset.Position(fd.Pos()).Offset
-							functionEnd := pass.Fset.Position(fd.End()).Offset
-							if c.position.Offset >= functionStart && c.position.Offset <= functionEnd {
-								key = fd.Name.Name + ":" + key
-								break
-							}
-						}
-					}
-				}
-			}
-		}
-
+		key := groupingKey(c, pass, cwd)
 		if existingConflictIndex, ok := conflictsMap[key]; ok {
 			// Grouping condition satisfied. Add new conflict to `similarConflicts` in `existingConflict`, and update groupedConflicts map
 			allConflicts[existingConflictIndex].addSimilarConflict(c)
@@ -138,3 +129,119 @@ func groupConflicts(allConflicts []conflict, pass *analysis.Pass, cwd string) []
 	}
 	return groupedConflicts
 }
+
+// groupingKey returns the key for grouping the conflict with others, i.e., conflicts with the same
+// key share the same nil path.
+func groupingKey(c conflict, pass *analysis.Pass, cwd string) string {
+	key := pathString(c.flow.nilPath)
+
+	// Handle the case of single assertion conflict separately
+	if len(c.flow.nilPath) == 0 && len(c.flow.nonnilPath) == 1 {
+		// This is the case of single assertion conflict. Use producer position and repr from the non-nil path as
+		// the key, if present, else use the producer and consumer repr as a heuristic key to group conflicts.
+		p := c.flow.nonnilPath[0]
+		key = p.producerRepr + ";" + p.consumerRepr
+		if p.producerPosition.IsValid() {
+			key = p.producerPosition.String() + ": " + p.producerRepr
+		} else {
+			// The heuristic of using producer and consumer repr as key may not work perfectly, especially when the
+			// error messages in two different functions are exactly the same. Consider the following example:
+			// ```
+			// 	func f1() {
+			//		mp := make(map[int]*int)
+			//		_ = *mp[0] // error message: "deep read from local variable `mp` lacking guarding; dereferenced"
+			// 	}
+			//
+			// 	func f2() {
+			//		mp := make(map[int]*int)
+			//		_ = *mp[0] // error message: "deep read from local variable `mp` lacking guarding; dereferenced"
+			// 	}
+			// ```
+			// Here, the two error messages are exactly the same, but they should not be grouped together as they are
+			// from different functions. To handle such cases, we prepend the enclosing function name to the key.
+			conf := pass.ResultOf[config.Analyzer].(*config.Config)
+			for _, file := range pass.Files {
+				// `fileName` stores the complete file path relative to the current working directory
+				fileName := pass.Fset.Position(file.FileStart).Filename
+				if fn, err := filepath.Rel(cwd, fileName); err == nil {
+					fileName = fn
+				}
+				// Check if the file is in scope and the conflict position is in the same file
+				if !conf.IsFileInScope(file) || fileName != c.position.Filename {
+					continue
+				}
+				for _, decl := range file.Decls {
+					// Check if the conflict position falls within the function's position range. If so, update the key to
+					// include the function name, and end the traversal.
+					if fd, ok := decl.(*ast.FuncDecl); ok {
+						functionStart := pass.Fset.Position(fd.Pos()).Offset
+						functionEnd := pass.Fset.Position(fd.End()).Offset
+						if c.position.Offset >= functionStart && c.position.Offset <= functionEnd {
+							key = fd.Name.Name + ":" + key
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+	return key
+}
+
+// _lineColumnRegex matches the line and column numbers of the positions (e.g., "foo.go:12:3"),
+// which may be embedded in the representations of the sites (e.g., call site results).
+var _lineColumnRegex = regexp.MustCompile(`(\.go):\d+(?::\d+)?`)
+
+// fingerprint returns the stable ID of the conflict. It is computed from the identity of the
+// source site of the conflict (package path, object path, and the kind of the site), the kind of
+// the final consumer, and the enclosing function of the reported position. None of them depends
+// on positions, so the ID is stable across unrelated edits that shift lines, and stays the same
+// as long as the same bug is reported in the same function.
+func (e *Engine) fingerprint(c conflict) string {
+	consumer := ""
+	if len(c.flow.nonnilPath) > 0 {
+		consumer = fmt.Sprintf("%T", c.flow.nonnilPath[len(c.flow.nonnilPath)-1].consumer)
+	}
+	site := _lineColumnRegex.ReplaceAllString(c.site, "$1")
+	sum := sha256.Sum256([]byte(site + ";" + consumer + ";" + e.enclosingFunction(c.position)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// diagnosticFingerprint returns the stable ID of a diagnostic added via Engine.AddDiagnostics. It
+// is computed from the category, the message (with any line and column numbers removed) and the
+// enclosing function of the diagnostic, which is stable across unrelated edits that shift lines
+// just like the fingerprints of the conflicts.
+func (e *Engine) diagnosticFingerprint(d analysis.Diagnostic) string {
+	message := _lineColumnRegex.ReplaceAllString(d.Message, "$1")
+	sum := sha256.Sum256([]byte(d.Category + ";" + message + ";" + e.enclosingFunctionAt(d.Pos)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// enclosingFunction returns the full name (e.g., "(*example.com/foo.T).Bar") of the function
+// declaration enclosing the position in the current package, or "" if there is none.
+func (e *Engine) enclosingFunction(position token.Position) string {
+	info, ok := e.files[position.Filename]
+	if !ok || info.isFake {
+		return ""
+	}
+	return e.enclosingFunctionAt(e.toPos(position))
+}
+
+// enclosingFunctionAt is the same as enclosingFunction but for a token.Pos in the current package.
+func (e *Engine) enclosingFunctionAt(pos token.Pos) string {
+	for _, file := range e.pass.Files {
+		if pos < file.FileStart || pos > file.FileEnd {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || pos < fd.Pos() || pos > fd.End() {
+				continue
+			}
+			if fn, ok := e.pass.TypesInfo.Defs[fd.Name].(*types.Func); ok {
+				return fn.FullName()
+			}
+		}
+	}
+	return ""
+}
@@ -26,6 +26,7 @@ import (
 	"slices"
 
 	"go.uber.org/nilaway/annotation"
+	"go.uber.org/nilaway/config"
 	"go.uber.org/nilaway/inference"
 	"go.uber.org/nilaway/util"
 	"golang.org/x/tools/go/analysis"
@@ -38,14 +39,38 @@ type fileInfo struct {
 	isFake bool
 }
 
+// Diagnostic bundles an analysis.Diagnostic with the structured nil flow(s) it is generated from,
+// such that drivers are able to render the flows in structured formats (e.g., SARIF) instead of
+// parsing the diagnostic message.
+type Diagnostic struct {
+	analysis.Diagnostic
+	// Flow is the nil flow of the conflict reported by this diagnostic.
+	Flow Flow
+	// SimilarFlows are the nil flows of the similar conflicts grouped under this diagnostic.
+	SimilarFlows []Flow
+	// Fingerprint is the stable ID of the conflict reported by this diagnostic (also shown in the
+	// message), which is computed from the source site, the kind of the final consumer and the
+	// enclosing function, and is hence stable across unrelated edits that shift lines. Note that
+	// multiple diagnostics may share the same fingerprint (e.g., the same nilable value
+	// dereferenced twice in a function). For the diagnostics added via Engine.AddDiagnostics, it
+	// is computed from the category, the message and the enclosing function instead. It is empty
+	// for internal errors.
+	Fingerprint string
+}
+
 // Engine is the main engine for generating diagnostics from conflicts.
 type Engine struct {
 	pass      *analysis.Pass
 	conflicts []conflict
+	// diagnostics stores the diagnostics generated outside of the engine (see AddDiagnostics).
+	diagnostics []analysis.Diagnostic
 	// files maps the file name (modulo the possible build-system prefix) to the token.File object
 	// for faster lookup when converting correct upstream position back to local token.Pos for
 	// reporting purposes.
 	files map[string]fileInfo
+	// truncatedFiles maps the truncated file names (see [util.TruncatePosition]) used in the nil
+	// flows back to the candidate file names used as keys in files.
+	truncatedFiles map[string][]string
 	// cwd is the current working directory for trimming the file names to get truly package- and
 	// build-system- (bazel for example adds a random sandbox prefix) independent positions.
 	cwd string
@@ -66,6 +91,7 @@ func NewEngine(pass *analysis.Pass) *Engine {
 	// incorrect token.Pos for error reporting purposes. Also see
 	// [inference.primitivizer.toPosition] for more detailed explanations.
 	files := make(map[string]fileInfo)
+	truncatedFiles := make(map[string][]string)
 	pass.Fset.Iterate(func(file *token.File) bool {
 		name, err := filepath.Rel(cwd, file.Name())
 		if err != nil {
@@ -90,18 +116,24 @@ func NewEngine(pass *analysis.Pass) *Engine {
 			file:   file,
 			isFake: isFake,
 		}
+
+		truncated := util.PortionAfterSep(name, "/", config.DirLevelsToPrintForTriggers)
+		truncatedFiles[truncated] = append(truncatedFiles[truncated], name)
 		return true
 	})
 
-	return &Engine{pass: pass, files: files, cwd: cwd}
+	return &Engine{pass: pass, files: files, truncatedFiles: truncatedFiles, cwd: cwd}
 }
 
-// Diagnostics generates diagnostics from the internally-stored conflicts. The grouping parameter
-// controls whether the conflicts with the same nil flow -- the part in the complete nil flow going
-// from a nilable source point to the conflict point -- are grouped together (under the first
-// diagnostic) for concise reporting. The returned slice of diagnostics are sorted by file names
-// and then offsets in the file.
-func (e *Engine) Diagnostics(grouping bool) []analysis.Diagnostic {
+// Diagnostics generates diagnostics from the internally-stored conflicts, along with the
+// structured nil flows they are generated from. The grouping parameter controls whether the
+// conflicts with the same nil flow -- the part in the complete nil flow going from a nilable
+// source point to the conflict point -- are grouped together (under the first diagnostic) for
+// concise reporting. Conflicts of the disabled categories or suppressed by `//nilaway:ignore`
+// directives are dropped, and so are the diagnostics added via AddDiagnostics. The returned slice
+// of diagnostics are sorted by file names and then offsets in the file, followed by the added
+// diagnostics and then the diagnostics on the misused directives (if enabled).
+func (e *Engine) Diagnostics(grouping bool) []Diagnostic {
 	// First sort the conflicts by position such that similar conflicts are grouped under the
 	// first diagnostic.
 	slices.SortFunc(e.conflicts, func(a, b conflict) int {
@@ -111,23 +143,73 @@ func (e *Engine) Diagnostics(grouping bool) []analysis.Diagnostic {
 		return cmp.Compare(a.position.Offset, b.position.Offset)
 	})
 
-	conflicts := e.conflicts
+	// Drop the conflicts of the disabled categories or suppressed by the ignore directives before
+	// grouping, such that the dropped conflicts do not hide the other conflicts in their groups.
+	conf := e.pass.ResultOf[config.Analyzer].(*config.Config)
+	suppressor := newSuppressor(e.pass)
+	conflicts := make([]conflict, 0, len(e.conflicts))
+	for _, c := range e.conflicts {
+		// Check the directives first such that the ones covering the conflicts of the disabled
+		// categories are still marked as used.
+		if !suppressor.suppressed(e.filePosition(c.position)) && conf.IsCategoryEnabled(c.category()) {
+			conflicts = append(conflicts, c)
+		}
+	}
 	if grouping {
 		// Group conflicts with the same nil path together for concise reporting.
-		conflicts = groupConflicts(e.conflicts, e.pass, e.cwd)
+		conflicts = groupConflicts(conflicts, e.pass, e.cwd)
 	}
 
 	// Build diagnostics from conflicts.
-	diagnostics := make([]analysis.Diagnostic, 0, len(conflicts))
+	diagnostics := make([]Diagnostic, 0, len(conflicts))
 	for _, c := range conflicts {
-		diagnostics = append(diagnostics, analysis.Diagnostic{
-			Pos:     e.toPos(c.position),
-			Message: c.String(),
+		var similarFlows []Flow
+		for _, s := range c.similarConflicts {
+			similarFlows = append(similarFlows, e.exportFlow(s.flow))
+		}
+		c.fingerprint = e.fingerprint(c)
+		message := c.String()
+		if conf.ConciseMessages {
+			message = c.conciseString()
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Diagnostic: analysis.Diagnostic{
+				Pos:            e.toPos(c.position),
+				Category:       c.category(),
+				Message:        message,
+				Related:        e.related(c),
+				SuggestedFixes: e.suggestedFixes(c),
+			},
+			Flow:         e.exportFlow(c.flow),
+			SimilarFlows: similarFlows,
+			Fingerprint:  c.fingerprint,
 		})
 	}
+
+	// The diagnostics generated outside of the engine are subject to the same filtering.
+	for _, d := range e.diagnostics {
+		if !suppressor.suppressed(e.pass.Fset.Position(d.Pos)) && conf.IsCategoryEnabled(d.Category) {
+			diagnostics = append(diagnostics, Diagnostic{Diagnostic: d, Fingerprint: e.diagnosticFingerprint(d)})
+		}
+	}
+
+	if conf.CheckIgnoreDirectives {
+		diagnostics = append(diagnostics, suppressor.diagnostics()...)
+	}
 	return diagnostics
 }
 
+// AddDiagnostics adds the diagnostics generated outside of the engine (e.g., the ones on the
+// malformed annotations) with the given category, such that they are also subject to the category
+// filtering and the `//nilaway:ignore` directives. They are reported after the diagnostics on the
+// conflicts.
+func (e *Engine) AddDiagnostics(category string, diagnostics []analysis.Diagnostic) {
+	for _, d := range diagnostics {
+		d.Category = category
+		e.diagnostics = append(e.diagnostics, d)
+	}
+}
+
 // AddSingleAssertionConflict adds a new single assertion conflict to the engine.
 func (e *Engine) AddSingleAssertionConflict(trigger annotation.FullTrigger) {
 	producer, consumer := trigger.Prestrings(e.pass)
@@ -141,14 +223,26 @@ func (e *Engine) AddSingleAssertionConflict(trigger annotation.FullTrigger) {
 	if filename, err := filepath.Rel(e.cwd, position.Filename); err == nil {
 		position.Filename = filename
 	}
+	// The site of the conflict is the producer site if it is available (i.e., annotation-based
+	// checks), otherwise we identify the source by the kind and the representation of the producer.
+	site := fmt.Sprintf("%T: %s", trigger.Producer.Annotation, trigger.Producer.Annotation.Prestring())
+	if key := trigger.Producer.Annotation.UnderlyingSite(); key != nil {
+		pkgPath := ""
+		if pkg := key.Object().Pkg(); pkg != nil {
+			pkgPath = pkg.Path()
+		}
+		site = pkgPath + ": " + key.String()
+	}
 	e.conflicts = append(e.conflicts, conflict{
 		position: position,
 		flow:     flow,
+		site:     site,
 	})
 }
 
-// AddOverconstraintConflict adds a new overconstraint conflict to the engine.
-func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.ExplainedBool) {
+// AddOverconstraintConflict adds a new overconstraint conflict on the site with the given
+// identity to the engine.
+func (e *Engine) AddOverconstraintConflict(site string, nilReason, nonnilReason inference.ExplainedBool) {
 	flow := nilFlow{}
 
 	// Build nil path by traversing the inference graph from `nilReason` part of the overconstraint failure.
@@ -197,9 +291,125 @@ func (e *Engine) AddOverconstraintConflict(nilReason, nonnilReason inference.Exp
 	e.conflicts = append(e.conflicts, conflict{
 		position: reportPosition,
 		flow:     flow,
+		site:     site,
 	})
 }
 
+// related returns the steps of the nil flow of the conflict (nil path first, then the nonnil
+// path) as related information, such that the editors are able to show a navigable trace. The
+// dereference points of the similar conflicts grouped under the conflict are also included.
+func (e *Engine) related(c conflict) []analysis.RelatedInformation {
+	var related []analysis.RelatedInformation
+	for _, nodes := range [...][]node{c.flow.nilPath, c.flow.nonnilPath} {
+		for _, n := range nodes {
+			// Steps that come from annotations do not have positions, skip them.
+			if !n.consumerPosition.IsValid() {
+				continue
+			}
+			related = append(related, analysis.RelatedInformation{
+				Pos:     e.toPos(e.resolvePosition(n.consumerPosition)),
+				Message: n.reason(),
+			})
+		}
+	}
+
+	for _, s := range c.similarConflicts {
+		if len(s.flow.nonnilPath) == 0 {
+			continue
+		}
+		last := s.flow.nonnilPath[len(s.flow.nonnilPath)-1]
+		if !last.consumerPosition.IsValid() {
+			continue
+		}
+		related = append(related, analysis.RelatedInformation{
+			Pos:     e.toPos(e.resolvePosition(last.consumerPosition)),
+			Message: "same nil source could also cause potential nil panic here: " + last.reason(),
+		})
+	}
+	return related
+}
+
+// exportFlow converts the internal nil flow to its exported form, where the truncated positions
+// are resolved back to complete positions.
+func (e *Engine) exportFlow(flow nilFlow) Flow {
+	convert := func(nodes []node) []FlowNode {
+		converted := make([]FlowNode, 0, len(nodes))
+		for _, n := range nodes {
+			converted = append(converted, FlowNode{
+				ProducerPosition: e.resolvePosition(n.producerPosition),
+				ConsumerPosition: e.resolvePosition(n.consumerPosition),
+				Producer:         n.producerRepr,
+				Consumer:         n.consumerRepr,
+			})
+		}
+		return converted
+	}
+	return Flow{NilPath: convert(flow.nilPath), NonNilPath: convert(flow.nonnilPath)}
+}
+
+// resolvePosition resolves the file name of a truncated position (see [util.TruncatePosition])
+// stored in the nil flows back to the complete file name (modulo the possible build-system
+// prefix). The position is returned as is if it is invalid or cannot be resolved.
+func (e *Engine) resolvePosition(position token.Position) token.Position {
+	if !position.IsValid() {
+		return position
+	}
+	if _, ok := e.files[position.Filename]; ok {
+		return position
+	}
+	candidates := e.truncatedFiles[position.Filename]
+	if len(candidates) == 0 {
+		return position
+	}
+
+	// Multiple files could share the same truncated name (e.g., "foo/bar.go" for both
+	// "a/foo/bar.go" and "b/foo/bar.go"). We prefer non-fake files (since they are the ones that
+	// are analyzed from source) that are closest to the files of the current package in the
+	// directory tree, and then break the ties by file names for determinism.
+	pkgDir := ""
+	if len(e.pass.Files) > 0 {
+		pkgDir = filepath.Dir(e.relName(e.pass.Fset.File(e.pass.Files[0].Pos()).Name()))
+	}
+	score := func(name string) int {
+		if e.files[name].isFake {
+			return -1
+		}
+		common := 0
+		for common < len(name) && common < len(pkgDir) && name[common] == pkgDir[common] {
+			common++
+		}
+		return common
+	}
+	best := candidates[0]
+	for _, name := range candidates[1:] {
+		if s, bestScore := score(name), score(best); s > bestScore || (s == bestScore && name < best) {
+			best = name
+		}
+	}
+	position.Filename = best
+	return position
+}
+
+// filePosition returns the position with the file name as in the file set (instead of relative
+// to the current working directory) after resolving the truncated file name, such that the file
+// can be located for parsing.
+func (e *Engine) filePosition(position token.Position) token.Position {
+	position = e.resolvePosition(position)
+	if info, ok := e.files[position.Filename]; ok {
+		position.Filename = info.file.Name()
+	}
+	return position
+}
+
+// relName returns the file name relative to the current working directory if possible, which is
+// the form of the file names stored in the engine.
+func (e *Engine) relName(name string) string {
+	if rel, err := filepath.Rel(e.cwd, name); err == nil {
+		return rel
+	}
+	return name
+}
+
 // _fakeFileMaxLines is the maximum number of lines that the archive importer will add to a (fake)
 // file when it imports a package. See [the importer code] for more details. We use this to create
 // more fake files when necessary (see [primitivizer.sitePos]).
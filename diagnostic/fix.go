@@ -0,0 +1,272 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// suggestedFixes returns the quick fixes for the conflict, which insert a nil guard for the local
+// variable that is consumed at the dereference point. If the results of the enclosing function
+// allow it, the fix inserts an early return (`if x == nil { return <zero values>, <error> }`)
+// before the statement containing the dereference; otherwise, it wraps the statement in
+// `if x != nil { ... }`. Nil is returned if no fix can be safely suggested.
+func (e *Engine) suggestedFixes(c conflict) []analysis.SuggestedFix {
+	// We only suggest fixes for the consumptions where a nil guard makes sense.
+	switch c.category() {
+	case config.CategoryDereference, config.CategoryFieldAccess, config.CategoryMapWrite, config.CategorySliceAccess:
+	default:
+		return nil
+	}
+
+	// The fixes can only be applied to the files of the current package.
+	info, ok := e.files[c.position.Filename]
+	if !ok || info.isFake {
+		return nil
+	}
+	var file *ast.File
+	for _, f := range e.pass.Files {
+		if e.pass.Fset.File(f.Pos()) == info.file {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return nil
+	}
+
+	pos := e.toPos(c.position)
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+	if len(path) == 0 {
+		return nil
+	}
+	ident, ok := path[0].(*ast.Ident)
+	if !ok || ident.Pos() != pos {
+		return nil
+	}
+	v, ok := e.pass.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || v.IsField() || v.Parent() == nil || v.Parent() == v.Pkg().Scope() {
+		return nil
+	}
+
+	// Find the innermost statement that directly resides in a statement list (such that we can
+	// insert statements before it or wrap it), and the innermost enclosing function.
+	var stmt ast.Stmt
+	var sig *types.Signature
+	for i, n := range path {
+		if stmt == nil && i+1 < len(path) {
+			if s, ok := n.(ast.Stmt); ok {
+				switch path[i+1].(type) {
+				case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+					stmt = s
+				}
+			}
+		}
+		if stmt != nil {
+			if fn, ok := n.(*ast.FuncLit); ok {
+				sig, _ = e.pass.TypesInfo.TypeOf(fn).(*types.Signature)
+				break
+			}
+			if fn, ok := n.(*ast.FuncDecl); ok {
+				if obj, ok := e.pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					sig, _ = obj.Type().(*types.Signature)
+				}
+				break
+			}
+		}
+	}
+	if stmt == nil || sig == nil {
+		return nil
+	}
+	// The variable must be declared before (and visible at) the statement, otherwise the guard
+	// would refer to an undefined variable.
+	if v.Pos() >= stmt.Pos() || !v.Parent().Contains(stmt.Pos()) {
+		return nil
+	}
+
+	indent := strings.Repeat("\t", e.pass.Fset.Position(stmt.Pos()).Column-1)
+	name := ident.Name
+
+	// Prefer inserting an early return if the enclosing function's results allow it.
+	if ret, edits, ok := e.earlyReturn(file, sig, name); ok {
+		text := fmt.Sprintf("if %s == nil {\n%s\t%s\n%s}\n%s", name, indent, ret, indent, indent)
+		return []analysis.SuggestedFix{{
+			Message:   fmt.Sprintf("Return early if `%s` is nil", name),
+			TextEdits: append(edits, analysis.TextEdit{Pos: stmt.Pos(), End: stmt.Pos(), NewText: []byte(text)}),
+		}}
+	}
+
+	// Wrapping a statement that declares variables would make them invisible to the subsequent
+	// statements, and wrapping a terminating statement would leave the nil path without one (e.g.,
+	// a function with results would miss its return), so we do not suggest fixes for such
+	// statements.
+	switch s := stmt.(type) {
+	case *ast.DeclStmt, *ast.LabeledStmt, *ast.ReturnStmt, *ast.BranchStmt:
+		return nil
+	case *ast.AssignStmt:
+		if s.Tok == token.DEFINE {
+			return nil
+		}
+	case *ast.ExprStmt:
+		if e.isPanicCall(s.X) {
+			return nil
+		}
+	}
+	return []analysis.SuggestedFix{{
+		Message: fmt.Sprintf("Guard the use with `if %s != nil`", name),
+		TextEdits: []analysis.TextEdit{
+			{Pos: stmt.Pos(), End: stmt.Pos(), NewText: []byte(fmt.Sprintf("if %s != nil {\n%s\t", name, indent))},
+			{Pos: stmt.End(), End: stmt.End(), NewText: []byte("\n" + indent + "}")},
+		},
+	}}
+}
+
+// isPanicCall returns if the expression is a call to the builtin `panic`.
+func (e *Engine) isPanicCall(expr ast.Expr) bool {
+	call, ok := astutil.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := astutil.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := e.pass.TypesInfo.Uses[ident].(*types.Builtin)
+	return ok && b.Name() == "panic"
+}
+
+// earlyReturn returns the return statement (and the extra edits for importing the "errors"
+// package if necessary) for returning early from a function with the given signature when the
+// variable is nil. The early return is only allowed if the function has no results or if its last
+// result is an error, and the zero values of all other results can be expressed in the file.
+func (e *Engine) earlyReturn(file *ast.File, sig *types.Signature, name string) (string, []analysis.TextEdit, bool) {
+	results := sig.Results()
+	if results.Len() == 0 {
+		return "return", nil, true
+	}
+	if !types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type()) {
+		return "", nil, false
+	}
+
+	values := make([]string, 0, results.Len())
+	for i := 0; i < results.Len()-1; i++ {
+		zero, ok := zeroValue(file, e.pass.Pkg, results.At(i).Type())
+		if !ok {
+			return "", nil, false
+		}
+		values = append(values, zero)
+	}
+	errorsName, edits, ok := importName(file, "errors", "errors")
+	if !ok {
+		return "", nil, false
+	}
+	values = append(values, fmt.Sprintf("%s.New(%s)", errorsName, strconv.Quote("unexpected nil "+name)))
+	return "return " + strings.Join(values, ", "), edits, true
+}
+
+// zeroValue returns the expression of the zero value of the given type that is valid in the file.
+func zeroValue(file *ast.File, pkg *types.Package, t types.Type) (string, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		// Type parameters also have interface underlying types, but nil is not their zero value.
+		if tp, ok := t.(*types.TypeParam); ok {
+			return "*new(" + tp.Obj().Name() + ")", true
+		}
+		return "nil", true
+	case *types.Struct, *types.Array:
+		if n, ok := t.(*types.Named); ok && n.Obj().Pkg() != pkg && !n.Obj().Exported() {
+			return "", false
+		}
+		valid := true
+		str := types.TypeString(t, func(p *types.Package) string {
+			if p == pkg {
+				return ""
+			}
+			name, ok := importedName(file, p.Path(), p.Name())
+			valid = valid && ok
+			return name
+		})
+		if !valid {
+			return "", false
+		}
+		return str + "{}", true
+	}
+	return "", false
+}
+
+// importName returns the name to refer to the package with the given path and name in the file,
+// along with the edits to import the package if it is not already imported.
+func importName(file *ast.File, path, pkgName string) (string, []analysis.TextEdit, bool) {
+	if name, ok := importedName(file, path, pkgName); ok {
+		return name, nil, true
+	}
+	for _, spec := range file.Imports {
+		if spec.Name != nil && spec.Name.Name == "." {
+			// The package might be dot-imported, do not risk conflicting declarations.
+			return "", nil, false
+		}
+	}
+
+	name := pkgName
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			return name, []analysis.TextEdit{{Pos: gen.Lparen + 1, End: gen.Lparen + 1, NewText: []byte("\n\t" + strconv.Quote(path))}}, true
+		}
+		return name, []analysis.TextEdit{{Pos: gen.Pos(), End: gen.Pos(), NewText: []byte("import " + strconv.Quote(path) + "\n")}}, true
+	}
+	return name, []analysis.TextEdit{{Pos: file.Name.End(), End: file.Name.End(), NewText: []byte("\n\nimport " + strconv.Quote(path))}}, true
+}
+
+// importedName returns the name to refer to the package with the given path and name if it is
+// imported in the file.
+func importedName(file *ast.File, path, pkgName string) (string, bool) {
+	for _, spec := range file.Imports {
+		if p, err := strconv.Unquote(spec.Path.Value); err != nil || p != path {
+			continue
+		}
+		if spec.Name == nil {
+			return pkgName, true
+		}
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return "", false
+		}
+		return spec.Name.Name, true
+	}
+	return "", false
+}
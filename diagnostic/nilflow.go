@@ -62,6 +62,8 @@ type node struct {
 	consumerPosition token.Position
 	producerRepr     string
 	consumerRepr     string
+	// consumer is the (unwrapped) consumer Prestring, which identifies the kind of the consumption.
+	consumer annotation.Prestring
 }
 
 // newNode creates a new node object from the given producer and consumer Prestrings.
@@ -82,8 +84,10 @@ func newNode(p annotation.Prestring, c annotation.Prestring) node {
 	if l, ok := c.(annotation.LocatedPrestring); ok {
 		nodeObj.consumerPosition = l.Location
 		nodeObj.consumerRepr = l.Contained.String()
+		nodeObj.consumer = l.Contained
 	} else if c != nil {
 		nodeObj.consumerRepr = c.String()
+		nodeObj.consumer = c
 	}
 
 	return nodeObj
@@ -91,11 +95,21 @@ func newNode(p annotation.Prestring, c annotation.Prestring) node {
 
 func (n *node) String() string {
 	posStr := "<no pos info>"
-	reasonStr := ""
-	if n.consumerPosition.IsValid() {
+	switch {
+	case n.consumerPosition.IsValid():
 		posStr = n.consumerPosition.String()
+	case n.producerPosition.IsValid():
+		// Nodes from annotations (e.g., in the source or the stub files) only carry the positions
+		// of the annotations.
+		posStr = n.producerPosition.String()
 	}
+	return fmt.Sprintf("\t- %s: %s", posStr, n.reason())
+}
 
+// reason returns the explanation of the node, i.e., the producer and consumer representations
+// joined together.
+func (n *node) reason() string {
+	reasonStr := ""
 	if len(n.producerRepr) > 0 {
 		reasonStr += n.producerRepr
 	}
@@ -105,8 +119,49 @@ func (n *node) String() string {
 		}
 		reasonStr += n.consumerRepr
 	}
+	return reasonStr
+}
+
+// Flow is the structured representation of a nil flow, exported for drivers that render the flow
+// in their own formats (e.g., SARIF) instead of the plain diagnostic message.
+type Flow struct {
+	// NilPath is the path of the flow from the nilable source to the point of conflict.
+	NilPath []FlowNode
+	// NonNilPath is the path of the flow from the point of conflict to the dereference point.
+	NonNilPath []FlowNode
+}
+
+// FlowNode is a single step in a Flow, where a value is produced and then consumed.
+type FlowNode struct {
+	// ProducerPosition is the position where the value is produced, it may be invalid if the
+	// producer expression is not present in the source.
+	ProducerPosition token.Position
+	// ConsumerPosition is the position where the value is consumed, it may be invalid if the
+	// step comes from an annotation instead of a program expression.
+	ConsumerPosition token.Position
+	// Producer is the explanation of the production.
+	Producer string
+	// Consumer is the explanation of the consumption.
+	Consumer string
+}
+
+// Position returns the position of the step, which is the consumer position, or the producer
+// position for the steps from annotations (e.g., in the source or the stub files) since they only
+// carry the positions of the annotations. The returned position is invalid if neither is valid.
+func (n FlowNode) Position() token.Position {
+	if n.ConsumerPosition.IsValid() {
+		return n.ConsumerPosition
+	}
+	return n.ProducerPosition
+}
 
-	return fmt.Sprintf("\t- %s: %s", posStr, reasonStr)
+// String returns the explanation of the step, i.e., the producer and consumer explanations
+// joined together.
+func (n FlowNode) String() string {
+	if n.Producer == "" || n.Consumer == "" {
+		return n.Producer + n.Consumer
+	}
+	return n.Producer + " " + n.Consumer
 }
 
 func pathString(nodes []node) string {
@@ -0,0 +1,256 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
+)
+
+// _ignoreDirective is the directive for suppressing NilAway diagnostics from source. It must be
+// followed by a reason, e.g., `//nilaway:ignore the map is always populated in init()`. The
+// directive suppresses the diagnostics reported on:
+//   - the same line, if it is a trailing comment or a standalone comment on the reported line;
+//   - the statement starting on the same line, if it is a trailing comment (only the header,
+//     e.g., `if cond {`, for the compound statements);
+//   - the statement directly following the comment group that contains the directive;
+//   - the function, if the directive is in the doc comment of the function.
+const _ignoreDirective = "//nilaway:ignore"
+
+// ignoreDirective is a parsed `//nilaway:ignore` directive.
+type ignoreDirective struct {
+	// pos is the position of the directive comment.
+	pos token.Pos
+	// reason is the justification given for the directive, directives without reasons do not
+	// suppress any diagnostics.
+	reason string
+	// startLine and endLine mark the range of lines where the diagnostics are suppressed. The
+	// ranges are line-based since the positions in the fake files of the other packages only have
+	// accurate line numbers (see [Engine.toPos]).
+	startLine, endLine int
+	// used indicates whether the directive has suppressed any diagnostics.
+	used bool
+}
+
+// suppressor collects the ignore directives, and decides if a diagnostic should be suppressed.
+type suppressor struct {
+	conf *config.Config
+	// fset is the file set for parsing the files of the other packages.
+	fset *token.FileSet
+	// directives maps the file names to the directives in the files. The files of the current
+	// package are parsed upfront, while the files of the other packages (where the conflicts found
+	// by multi-package inference could be reported) are parsed lazily when needed.
+	directives map[string][]*ignoreDirective
+	// local is the directives in the files of the current package, which are checked for misuses.
+	local []*ignoreDirective
+}
+
+// newSuppressor parses the ignore directives in all in-scope files of the current package.
+func newSuppressor(pass *analysis.Pass) *suppressor {
+	s := &suppressor{
+		conf:       pass.ResultOf[config.Analyzer].(*config.Config),
+		fset:       token.NewFileSet(),
+		directives: make(map[string][]*ignoreDirective),
+	}
+	for _, file := range pass.Files {
+		tf := pass.Fset.File(file.Pos())
+		if tf == nil {
+			continue
+		}
+		var directives []*ignoreDirective
+		if s.conf.IsFileInScope(file) {
+			directives = parseIgnoreDirectives(pass.Fset, file)
+		}
+		s.directives[tf.Name()] = directives
+		s.local = append(s.local, directives...)
+	}
+	return s
+}
+
+// parseIgnoreDirectives parses the ignore directives in the file and computes their ranges.
+func parseIgnoreDirectives(fset *token.FileSet, file *ast.File) []*ignoreDirective {
+	tf := fset.File(file.Pos())
+	if tf == nil {
+		return nil
+	}
+
+	// Find all directives first, each of them suppresses its own line by default.
+	var directives []*ignoreDirective
+	groupOf := make(map[*ignoreDirective]*ast.CommentGroup)
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			reason, ok := parseIgnoreDirective(c.Text)
+			if !ok {
+				continue
+			}
+			line := tf.Line(c.Pos())
+			d := &ignoreDirective{pos: c.Pos(), reason: reason, startLine: line, endLine: line}
+			directives = append(directives, d)
+			groupOf[d] = group
+		}
+	}
+	if len(directives) == 0 {
+		return nil
+	}
+
+	// firstCode maps the line numbers to the first position of code on the line, which is used to
+	// tell whether a comment is a trailing comment (i.e., there is code before it on the line).
+	firstCode := make(map[int]token.Pos)
+	mark := func(pos token.Pos) {
+		line := tf.Line(pos)
+		if p, ok := firstCode[line]; !ok || pos < p {
+			firstCode[line] = pos
+		}
+	}
+	stmtsByLine := make(map[int]ast.Stmt)
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case nil, *ast.File, *ast.Comment, *ast.CommentGroup:
+			return true
+		case *ast.FuncDecl:
+			// The directives in the doc comment of the function suppress the entire function.
+			for _, d := range directives {
+				if groupOf[d] == n.Doc {
+					d.startLine, d.endLine = tf.Line(n.Pos()), tf.Line(n.End())
+				}
+			}
+		case ast.Stmt:
+			line := tf.Line(n.Pos())
+			if _, ok := stmtsByLine[line]; !ok {
+				stmtsByLine[line] = n
+			}
+		}
+		mark(n.Pos())
+		mark(n.End() - 1)
+		return true
+	})
+
+	for _, d := range directives {
+		group := groupOf[d]
+		if p, ok := firstCode[tf.Line(group.Pos())]; ok && p < group.Pos() {
+			// The trailing directives suppress the (multi-line) statement starting on their lines.
+			if stmt, ok := stmtsByLine[d.startLine]; ok {
+				d.endLine = max(d.endLine, tf.Line(stmtHeaderEnd(stmt)))
+			}
+			continue
+		}
+		// The standalone directives suppress the statement directly following their comment groups.
+		if stmt, ok := stmtsByLine[tf.Line(group.End())+1]; ok {
+			d.endLine = max(d.endLine, tf.Line(stmt.End()))
+		}
+	}
+	return directives
+}
+
+// stmtHeaderEnd returns the end of the header of the compound statement (e.g., `if cond {`), such
+// that the trailing directives on the headers do not suppress the entire bodies, or the end of the
+// statement otherwise.
+func stmtHeaderEnd(stmt ast.Stmt) token.Pos {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		return stmt.Lbrace
+	case *ast.IfStmt:
+		return stmt.Body.Lbrace
+	case *ast.ForStmt:
+		return stmt.Body.Lbrace
+	case *ast.RangeStmt:
+		return stmt.Body.Lbrace
+	case *ast.SwitchStmt:
+		return stmt.Body.Lbrace
+	case *ast.TypeSwitchStmt:
+		return stmt.Body.Lbrace
+	case *ast.SelectStmt:
+		return stmt.Body.Lbrace
+	case *ast.CaseClause:
+		return stmt.Colon
+	case *ast.CommClause:
+		return stmt.Colon
+	case *ast.LabeledStmt:
+		return stmt.Colon
+	}
+	return stmt.End()
+}
+
+// parseIgnoreDirective parses the comment text and returns the reason if the comment is an ignore
+// directive. The reason is the text following the directive up to any nested `//` comment.
+func parseIgnoreDirective(text string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, _ignoreDirective)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	if i := strings.Index(rest, "//"); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// suppressed returns true if the diagnostic at the given position is suppressed by any directive
+// with a reason, and marks the matching directives as used.
+func (s *suppressor) suppressed(position token.Position) bool {
+	if !position.IsValid() {
+		return false
+	}
+	directives, ok := s.directives[position.Filename]
+	if !ok {
+		directives = s.parseFile(position.Filename)
+		s.directives[position.Filename] = directives
+	}
+
+	suppressed := false
+	for _, d := range directives {
+		if d.reason != "" && d.startLine <= position.Line && position.Line <= d.endLine {
+			d.used = true
+			suppressed = true
+		}
+	}
+	return suppressed
+}
+
+// parseFile parses the ignore directives in the file of another package, which is not available
+// in the pass. Note that the files that cannot be read (e.g., in sandboxed builds) have no
+// directives.
+func (s *suppressor) parseFile(filename string) []*ignoreDirective {
+	file, err := parser.ParseFile(s.fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil || !s.conf.IsFileInScope(file) {
+		return nil
+	}
+	return parseIgnoreDirectives(s.fset, file)
+}
+
+// diagnostics returns the diagnostics for the directives in the current package that either lack
+// a reason or have not suppressed any diagnostics.
+func (s *suppressor) diagnostics() []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, d := range s.local {
+		var msg string
+		switch {
+		case d.reason == "":
+			msg = "`" + _ignoreDirective + "` directive must be followed by a reason"
+		case !d.used:
+			msg = "unused `" + _ignoreDirective + "` directive"
+		default:
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Diagnostic: analysis.Diagnostic{Pos: d.pos, Message: msg},
+		})
+	}
+	return diagnostics
+}
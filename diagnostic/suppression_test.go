@@ -0,0 +1,55 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostic
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/config"
+)
+
+func TestSuppressor_OtherPackageFiles(t *testing.T) {
+	t.Parallel()
+
+	// The conflicts found by multi-package inference could be reported in the files of the other
+	// packages, whose directives must be parsed from the files on demand.
+	filename := filepath.Join(t.TempDir(), "upstream.go")
+	src := `package upstream
+
+func foo(x *int) {
+	print( //nilaway:ignore the statement starting on this line is suppressed
+		*x,
+	)
+	print(*x)
+}
+`
+	require.NoError(t, os.WriteFile(filename, []byte(src), 0o600))
+
+	s := &suppressor{
+		conf:       &config.Config{},
+		fset:       token.NewFileSet(),
+		directives: make(map[string][]*ignoreDirective),
+	}
+	require.True(t, s.suppressed(token.Position{Filename: filename, Line: 5, Column: 3}))
+	require.False(t, s.suppressed(token.Position{Filename: filename, Line: 7, Column: 8}))
+	require.False(t, s.suppressed(token.Position{Filename: filepath.Join(t.TempDir(), "missing.go"), Line: 5}))
+
+	// The directives in the other packages are checked when analyzing their own packages.
+	require.Empty(t, s.diagnostics())
+}
@@ -20,6 +20,7 @@ import (
 	"cmp"
 	"encoding/gob"
 	"fmt"
+	"go/token"
 	"slices"
 
 	"go.uber.org/nilaway/annotation"
@@ -31,7 +32,9 @@ import (
 // This makes the inference engine independent of the diagnostic generation logic.
 type conflictHandler interface {
 	AddSingleAssertionConflict(trigger annotation.FullTrigger)
-	AddOverconstraintConflict(nilExplanation, nonnilExplanation ExplainedBool)
+	// AddOverconstraintConflict adds a conflict on the site with the given identity (see
+	// primitiveSite.identity), which is both explained to be nilable and nonnil.
+	AddOverconstraintConflict(site string, nilExplanation, nonnilExplanation ExplainedBool)
 }
 
 // Engine is the structure responsible for running the inference: it contains methods to run
@@ -53,6 +56,13 @@ type Engine struct {
 	// controls any triggers. This field is for internal use in the struct only and should not be
 	// accessed elsewhere.
 	controlledTriggersBySite map[primitiveSite]map[annotation.FullTrigger]bool
+	// recordGraph indicates whether the observed implications should be recorded in graphEdges
+	// for dumping the implication graph (see RecordGraph).
+	recordGraph bool
+	// observingUpstream indicates whether the engine is currently observing upstream information.
+	observingUpstream bool
+	// graphEdges stores the observed implications if recordGraph is set.
+	graphEdges []graphEdge
 }
 
 // NewEngine constructs an inference engine that is ready to run inference.
@@ -72,6 +82,19 @@ func (e *Engine) InferredMap() *InferredMap {
 	return e.inferredMap
 }
 
+// RecordGraph makes the engine record all observed implications, such that the complete
+// implication graph can be obtained via Engine.Graph later. Note that the implications are
+// otherwise dropped once the sites are determined, so this must be called before any "Observe*"
+// methods.
+func (e *Engine) RecordGraph() {
+	e.recordGraph = true
+}
+
+// Graph returns the implication graph built by the engine so far, see Engine.RecordGraph.
+func (e *Engine) Graph() *Graph {
+	return e.inferredMap.graph(e.pass.Pkg.Path(), e.graphEdges)
+}
+
 // ObserveUpstream imports all information from upstream dependencies. Specifically, it iterates
 // over the direct imports of the passed pass's package, using the Facts mechanism to observe any
 // InferredMap's that were computed by multi-package inference for that imported package.
@@ -80,6 +103,9 @@ func (e *Engine) InferredMap() *InferredMap {
 // added to Mapping but not UpstreamMapping, then, on a call to Export, only the information
 // present in Mapping but not UpstreamMapping is exported to ensure minimization of output.
 func (e *Engine) ObserveUpstream() {
+	e.observingUpstream = true
+	defer func() { e.observingUpstream = false }()
+
 	var facts []analysis.PackageFact
 	for _, packageFact := range e.pass.AllPackageFacts() {
 		// We only care about NilAway-related facts here.
@@ -104,6 +130,11 @@ func (e *Engine) ObserveUpstream() {
 				// This can yield an overconstrainedConflict if the current map disagrees on the
 				// value of the site.
 				e.observeSiteExplanation(site, v.Bool)
+				// Since only the incremental information is exported, the package exporting the
+				// determined value is the one that determined the site.
+				if _, ok := e.inferredMap.determinedBy[site]; !ok {
+					e.inferredMap.determinedBy[site] = f.Package.Path()
+				}
 			case *UndeterminedVal:
 				// Observe all forward implications from this site.
 				for _, p := range v.Implicates.Pairs {
@@ -134,14 +165,42 @@ func (e *Engine) ObserveUpstream() {
 // non-syntactically present annotations that simply arose from defaults.
 // In this latter case, the subsequent calls to observeAssertion below cannot determine any local
 // annotation sites, because they're all already determined, but they can yield failures.
-func (e *Engine) ObserveAnnotations(pkgAnnotations *annotation.ObservedMap, mode ModeOfInference) {
-	pkgAnnotations.Range(func(key annotation.Key, isDeep bool, val bool) {
-		site := e.primitive.site(key, isDeep)
+// The annotations declared in the external stub files (for the sites in this package and its
+// imports) are always observed as syntactic annotations, positioned at the stub files. However, the
+// stubs only take precedence over the defaults: a stub is ignored if its site is annotated in the
+// source code, or if it has already been determined by an upstream package (which has observed
+// the same stub, or an annotation in its source code, when it was analyzed).
+func (e *Engine) ObserveAnnotations(pkgAnnotations *annotation.ObservedMap, stubs *annotation.Stubs, mode ModeOfInference) {
+	observe := func(site primitiveSite, val bool, pos token.Position) {
 		if val {
-			e.observeSiteExplanation(site, TrueBecauseAnnotation{AnnotationPos: site.Position})
+			e.observeSiteExplanation(site, TrueBecauseAnnotation{AnnotationPos: pos})
 		} else {
-			e.observeSiteExplanation(site, FalseBecauseAnnotation{AnnotationPos: site.Position})
+			e.observeSiteExplanation(site, FalseBecauseAnnotation{AnnotationPos: pos})
 		}
+	}
+
+	annotated := make(map[primitiveSite]bool)
+	pkgAnnotations.Range(func(key annotation.Key, isDeep bool, _ bool) {
+		annotated[e.primitive.site(key, isDeep)] = true
+	}, true /* setSitesOnly */)
+
+	stubbed := make(map[primitiveSite]bool)
+	stubs.Range(e.pass.Pkg, func(key annotation.Key, isDeep bool, val bool, pos token.Position) {
+		site := e.primitive.site(key, isDeep)
+		if _, ok := e.inferredMap.determinedBy[site]; ok || annotated[site] {
+			return
+		}
+		stubbed[site] = true
+		observe(site, val, pos)
+	})
+
+	pkgAnnotations.Range(func(key annotation.Key, isDeep bool, val bool) {
+		site := e.primitive.site(key, isDeep)
+		// The defaults (observed in NoInfer mode) of the stubbed sites are overridden by the stubs.
+		if stubbed[site] {
+			return
+		}
+		observe(site, val, site.Position)
 	}, mode != NoInfer)
 }
 
@@ -356,7 +415,7 @@ func (e *Engine) observeSiteExplanation(site primitiveSite, siteExplained Explai
 		if !v.Bool.Val() {
 			trueExplanation, falseExplanation = falseExplanation, trueExplanation
 		}
-		e.diagnosticEngine.AddOverconstraintConflict(trueExplanation, falseExplanation)
+		e.diagnosticEngine.AddOverconstraintConflict(site.identity(), trueExplanation, falseExplanation)
 
 		// Even though we have a conflict, we still need to make sure to activate any controlled
 		// triggers that are waiting on this site, so that we would not miss processing any
@@ -430,6 +489,15 @@ func (e *Engine) observeImplication(
 	consumerSite primitiveSite,
 	assertion primitiveFullTrigger,
 ) {
+	if e.recordGraph {
+		e.graphEdges = append(e.graphEdges, graphEdge{
+			from:      producerSite,
+			to:        consumerSite,
+			assertion: assertion,
+			upstream:  e.observingUpstream,
+		})
+	}
+
 	// When we observe an implication between the producer site (PS) and consumer site (CS), we
 	// check their existing values in the inferred map (denoted as P and C) and behave accordingly:
 	// * If either P or C is determined, the other site will be determined. Note that we do not
@@ -570,4 +638,6 @@ func GobRegister() {
 	gob.RegisterName(nextStr(), annotation.RecvPassPrestring{})
 	gob.RegisterName(nextStr(), annotation.MethodRecvDeepPrestring{})
 	gob.RegisterName(nextStr(), annotation.FldReturnPrestring{})
+	gob.RegisterName(nextStr(), annotation.ElemReadPrestring{})
+	gob.RegisterName(nextStr(), annotation.ElemAssignPrestring{})
 }
@@ -0,0 +1,229 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The values of the sites in the dumped implication graphs.
+const (
+	GraphValueNilable      = "nilable"
+	GraphValueNonnil       = "nonnil"
+	GraphValueUndetermined = "undetermined"
+)
+
+// Graph is a snapshot of the implication graph built by the inference engine for a package. It
+// contains all sites in the inferred map along with their (determined) values, and all
+// implications observed by the engine, including the ones that are no longer stored in the map
+// since their sites are already determined. It is meant for debugging the inference only, e.g.,
+// understanding a confusing overconstraint conflict beyond the single path in its diagnostic.
+type Graph struct {
+	// Package is the path of the package the graph is built for.
+	Package string `json:"package"`
+	// Sites are the nodes of the graph.
+	Sites []GraphSite `json:"sites"`
+	// Implications are the edges of the graph.
+	Implications []GraphImplication `json:"implications"`
+}
+
+// GraphSite is a site (i.e., a node) in the implication graph.
+type GraphSite struct {
+	// ID is the ID of the site referred to by the implications.
+	ID int `json:"id"`
+	// Site is the string representation of the site.
+	Site string `json:"site"`
+	// Package is the path of the package the site resides in.
+	Package string `json:"package"`
+	// Position is the position of the site.
+	Position string `json:"position"`
+	// Upstream indicates whether the site is imported from upstream packages via facts.
+	Upstream bool `json:"upstream"`
+	// Value is the value of the site, i.e., GraphValueNilable, GraphValueNonnil, or
+	// GraphValueUndetermined.
+	Value string `json:"value"`
+	// Explanation explains why the site is determined, it is empty for undetermined sites.
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// GraphImplication is an implication (i.e., an edge) in the implication graph, meaning the `To`
+// site must be nilable if the `From` site is nilable (or equivalently, the `From` site must be
+// nonnil if the `To` site is nonnil).
+type GraphImplication struct {
+	// From and To are the IDs of the sites.
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Position is the position of the assertion that generates the implication.
+	Position string `json:"position"`
+	// Producer and Consumer describe the assertion that generates the implication.
+	Producer string `json:"producer"`
+	Consumer string `json:"consumer"`
+	// Upstream indicates whether the implication is imported from upstream packages via facts.
+	Upstream bool `json:"upstream"`
+}
+
+// graphEdge is an implication observed by the inference engine.
+type graphEdge struct {
+	from, to  primitiveSite
+	assertion primitiveFullTrigger
+	upstream  bool
+}
+
+// graph builds the implication graph from the sites in the map and the given implications
+// observed for the package.
+func (i *InferredMap) graph(pkgPath string, edges []graphEdge) *Graph {
+	g := &Graph{Package: pkgPath, Sites: []GraphSite{}, Implications: []GraphImplication{}}
+
+	ids := make(map[primitiveSite]int)
+	id := func(site primitiveSite) int {
+		if id, ok := ids[site]; ok {
+			return id
+		}
+		_, upstream := i.upstreamMapping[site]
+		s := GraphSite{
+			ID:       len(g.Sites),
+			Site:     site.String(),
+			Package:  site.PkgPath,
+			Position: site.Position.String(),
+			Upstream: upstream,
+			Value:    GraphValueUndetermined,
+		}
+		if v, ok := i.mapping.Load(site); ok {
+			if determined, ok := v.(*DeterminedVal); ok {
+				s.Value = GraphValueNonnil
+				if determined.Bool.Val() {
+					s.Value = GraphValueNilable
+				}
+				s.Explanation = determined.Bool.String()
+			}
+		}
+		ids[site] = s.ID
+		g.Sites = append(g.Sites, s)
+		return s.ID
+	}
+	addImplication := func(from, to primitiveSite, assertion primitiveFullTrigger, upstream bool) {
+		g.Implications = append(g.Implications, GraphImplication{
+			From:     id(from),
+			To:       id(to),
+			Position: assertion.Position.String(),
+			Producer: assertion.ProducerRepr.String(),
+			Consumer: assertion.ConsumerRepr.String(),
+			Upstream: upstream,
+		})
+	}
+
+	// The same implication may be observed multiple times (e.g., from both of its sites when
+	// importing upstream information), so we only keep the first one.
+	seen := make(map[[2]primitiveSite]bool)
+	for _, e := range edges {
+		if key := [2]primitiveSite{e.from, e.to}; !seen[key] {
+			seen[key] = true
+			addImplication(e.from, e.to, e.assertion, e.upstream)
+		}
+	}
+
+	// Then add all sites in the map, along with the implications stored in the map that are not
+	// observed by the engine (e.g., if the engine did not record them).
+	for _, p := range i.mapping.Pairs {
+		id(p.Key)
+		v, ok := p.Value.(*UndeterminedVal)
+		if !ok {
+			continue
+		}
+		for _, implicate := range v.Implicates.Pairs {
+			if key := [2]primitiveSite{p.Key, implicate.Key}; !seen[key] {
+				seen[key] = true
+				_, upstream := i.upstreamMapping[p.Key]
+				addImplication(p.Key, implicate.Key, implicate.Value, upstream)
+			}
+		}
+	}
+	return g
+}
+
+// Dump writes the graph to the directory in both DOT (<escaped package path>.dot) and JSON
+// (<escaped package path>.json) formats.
+func (g *Graph) Dump(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	base := filepath.Join(dir, url.PathEscape(g.Package))
+
+	var dot strings.Builder
+	if err := g.WriteDOT(&dot); err != nil {
+		return err
+	}
+	if err := os.WriteFile(base+".dot", []byte(dot.String()), 0o644); err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(base+".json", append(out, '\n'), 0o644)
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format, where the nilable sites are red, the
+// nonnil sites are green, and the upstream sites and implications are dashed.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", quoteDOT(g.Package))
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, s := range g.Sites {
+		label := s.Site + "\n" + s.Package + "\n" + s.Position
+		attrs := []string{"label=" + quoteDOT(label)}
+		switch s.Value {
+		case GraphValueNilable:
+			attrs = append(attrs, "color=red")
+		case GraphValueNonnil:
+			attrs = append(attrs, "color=darkgreen")
+		}
+		if s.Explanation != "" {
+			attrs = append(attrs, "tooltip="+quoteDOT(s.Explanation))
+		}
+		if s.Upstream {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&sb, "\tn%d [%s];\n", s.ID, strings.Join(attrs, ", "))
+	}
+	for _, i := range g.Implications {
+		label := i.Producer + " " + i.Consumer + "\n" + i.Position
+		attrs := []string{"label=" + quoteDOT(label)}
+		if i.Upstream {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(&sb, "\tn%d -> n%d [%s];\n", i.From, i.To, strings.Join(attrs, ", "))
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// quoteDOT quotes the string as a DOT string literal, where only the double quotes (and the
+// backslashes) need to be escaped and the newlines are represented as "\n".
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,93 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/nilaway/annotation"
+)
+
+func TestGraph(t *testing.T) {
+	t.Parallel()
+
+	site := func(pkg, repr string, line int) primitiveSite {
+		return primitiveSite{
+			Position: token.Position{Filename: pkg + "/foo.go", Line: line, Column: 1},
+			PkgPath:  pkg,
+			Repr:     repr,
+		}
+	}
+	assertion := func(line int) primitiveFullTrigger {
+		return primitiveFullTrigger{
+			Position:     token.Position{Filename: "foo.go", Line: line, Column: 1},
+			ProducerRepr: annotation.GlobalVarAssignDeepPrestring{VarName: "bar"},
+			ConsumerRepr: annotation.GlobalVarAssignPrestring{VarName: "foo"},
+		}
+	}
+
+	upstream := site("example.com/upstream", "Result 0 of Function Foo", 1)
+	local := site("example.com/local", "Param 0 of Function bar", 2)
+	determined := site("example.com/local", "Result 0 of Function baz", 3)
+
+	m := newInferredMap(nil /* primitivizer */)
+	m.StoreImplication(upstream, local, assertion(10))
+	m.upstreamMapping[upstream] = m.mapping.Value(upstream).copy()
+	m.StoreDetermined(determined, TrueBecauseAnnotation{AnnotationPos: token.Position{Filename: "foo.go", Line: 3}})
+
+	// The implication from the local site to the determined site is no longer stored in the map,
+	// but it is observed (and recorded) by the engine.
+	edges := []graphEdge{
+		{from: upstream, to: local, assertion: assertion(10), upstream: true},
+		{from: local, to: determined, assertion: assertion(20)},
+	}
+	g := m.graph("example.com/local", edges)
+	require.Equal(t, []GraphSite{
+		{ID: 0, Site: "Result 0 of Function Foo", Package: "example.com/upstream", Position: "example.com/upstream/foo.go:1:1", Upstream: true, Value: GraphValueUndetermined},
+		{ID: 1, Site: "Param 0 of Function bar", Package: "example.com/local", Position: "example.com/local/foo.go:2:1", Value: GraphValueUndetermined},
+		{ID: 2, Site: "Result 0 of Function baz", Package: "example.com/local", Position: "example.com/local/foo.go:3:1", Value: GraphValueNilable, Explanation: "NILABLE because it is annotated as so"},
+	}, g.Sites)
+	require.Len(t, g.Implications, 2)
+	require.Equal(t, GraphImplication{From: 0, To: 1, Position: "foo.go:10:1", Producer: assertion(10).ProducerRepr.String(), Consumer: assertion(10).ConsumerRepr.String(), Upstream: true}, g.Implications[0])
+	require.Equal(t, 1, g.Implications[1].From)
+	require.Equal(t, 2, g.Implications[1].To)
+	require.False(t, g.Implications[1].Upstream)
+
+	// The implications stored in the map should be included even if they are not recorded.
+	require.Equal(t, g.Implications[:1], m.graph("example.com/local", nil).Implications)
+
+	var dot strings.Builder
+	require.NoError(t, g.WriteDOT(&dot))
+	require.Contains(t, dot.String(), `digraph "example.com/local" {`)
+	require.Contains(t, dot.String(), `n0 [label="Result 0 of Function Foo\nexample.com/upstream\nexample.com/upstream/foo.go:1:1", style=dashed];`)
+	require.Contains(t, dot.String(), `n2 [label="Result 0 of Function baz\nexample.com/local\nexample.com/local/foo.go:3:1", color=red, tooltip="NILABLE because it is annotated as so"];`)
+	require.Contains(t, dot.String(), "n0 -> n1 [")
+	require.Contains(t, dot.String(), "n1 -> n2 [")
+
+	dir := t.TempDir()
+	require.NoError(t, g.Dump(dir))
+	require.FileExists(t, filepath.Join(dir, "example.com%2Flocal.dot"))
+	content, err := os.ReadFile(filepath.Join(dir, "example.com%2Flocal.json"))
+	require.NoError(t, err)
+	var decoded Graph
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	require.Equal(t, *g, decoded)
+}
@@ -44,6 +44,9 @@ type InferredMap struct {
 	primitive       *primitivizer
 	upstreamMapping map[primitiveSite]InferredVal
 	mapping         *orderedmap.OrderedMap[primitiveSite, InferredVal]
+	// determinedBy maps the sites determined by upstream packages to the paths of those packages,
+	// which is only used for explaining the sites and is never exported.
+	determinedBy map[primitiveSite]string
 }
 
 // newInferredMap returns a new, empty InferredMap.
@@ -52,6 +55,7 @@ func newInferredMap(primitive *primitivizer) *InferredMap {
 		primitive:       primitive,
 		upstreamMapping: make(map[primitiveSite]InferredVal),
 		mapping:         orderedmap.New[primitiveSite, InferredVal](),
+		determinedBy:    make(map[primitiveSite]string),
 	}
 }
 
@@ -289,6 +293,21 @@ func (i *InferredMap) CheckGlobalVarAnn(v *types.Var) (annotation.Val, bool) {
 	return i.checkAnnotationKey(&annotation.GlobalVarAnnotationKey{VarDecl: v})
 }
 
+// CheckLocalVarAnn checks this InferredMap for a concrete mapping of the local variable key provided
+func (i *InferredMap) CheckLocalVarAnn(v *types.Var) (annotation.Val, bool) {
+	return i.checkAnnotationKey(&annotation.LocalVarAnnotationKey{VarDecl: v})
+}
+
+// CheckElemAnn checks this InferredMap for a concrete mapping of the nested element key provided.
+// The shallow nilability of the element is the deep nilability of the shallower key.
+func (i *InferredMap) CheckElemAnn(key *annotation.ElemAnnotationKey) (annotation.Val, bool) {
+	var shallower annotation.Key = key.Base
+	if key.Depth > 1 {
+		shallower = &annotation.ElemAnnotationKey{Base: key.Base, Depth: key.Depth - 1}
+	}
+	return i.checkSites(i.primitive.site(shallower, true), i.primitive.site(key, true))
+}
+
 // CheckFuncCallSiteParamAnn checks this InferredMap for a concrete mapping of the call site param
 // key provided.
 func (i *InferredMap) CheckFuncCallSiteParamAnn(key *annotation.CallSiteParamAnnotationKey) (annotation.Val, bool) {
@@ -301,10 +320,55 @@ func (i *InferredMap) CheckFuncCallSiteRetAnn(key *annotation.CallSiteRetAnnotat
 	return i.checkAnnotationKey(key)
 }
 
+// SiteExplanation explains the nilability of a site in the inferred map.
+type SiteExplanation struct {
+	// Site is the string representation of the site.
+	Site string
+	// Explanation is the reason why the site is determined to be nilable or nonnil, it is nil if
+	// the site is not determined (i.e., it is only constrained by the implications with other
+	// undetermined sites).
+	Explanation ExplainedBool
+	// DeterminedBy is the path of the upstream package that determined the site, it is empty if
+	// the site is determined in the current package (or not determined at all).
+	DeterminedBy string
+}
+
+// Explain returns the explanations of the shallow and the deep nilabilities of the site of the
+// key, if they are present in the map.
+func (i *InferredMap) Explain(key annotation.Key) []SiteExplanation {
+	var explanations []SiteExplanation
+	for _, isDeep := range [...]bool{false, true} {
+		site := i.primitive.site(key, isDeep)
+		val, ok := i.mapping.Load(site)
+		if !ok {
+			continue
+		}
+		e := SiteExplanation{Site: site.String()}
+		if v, ok := val.(*DeterminedVal); ok {
+			e.Explanation = v.Bool
+			e.DeterminedBy = i.determinedBy[site]
+		}
+		explanations = append(explanations, e)
+	}
+	return explanations
+}
+
+// Determined returns the explanation of the determined shallow (or deep) nilability of the site of
+// the key, or nil if the site is not present in the map or not determined.
+func (i *InferredMap) Determined(key annotation.Key, isDeep bool) ExplainedBool {
+	if v, ok := i.mapping.Value(i.primitive.site(key, isDeep)).(*DeterminedVal); ok {
+		return v.Bool
+	}
+	return nil
+}
+
 func (i *InferredMap) checkAnnotationKey(key annotation.Key) (annotation.Val, bool) {
-	shallowKey := i.primitive.site(key, false)
-	deepKey := i.primitive.site(key, true)
+	return i.checkSites(i.primitive.site(key, false), i.primitive.site(key, true))
+}
 
+// checkSites returns the annotation value formed by the determined values of the shallow and
+// deep sites provided, and false if any of them is not determined.
+func (i *InferredMap) checkSites(shallowKey, deepKey primitiveSite) (annotation.Val, bool) {
 	shallowVal, shallowOk := i.mapping.Load(shallowKey)
 	deepVal, deepOk := i.mapping.Load(deepKey)
 	if !shallowOk || !deepOk {
@@ -17,6 +17,7 @@ package inference
 import (
 	"fmt"
 	"go/token"
+	"go/types"
 	"os"
 	"path/filepath"
 
@@ -93,6 +94,19 @@ func (s *primitiveSite) String() string {
 	return deepStr + s.Repr
 }
 
+// identity returns the position-independent identity of the site, which consists of the package
+// path, the object path (only available for exported objects), and the representation of the
+// site (which encodes the kind of the site, e.g., "Result 0 of Function foo"). Unlike the
+// position, the identity is stable across unrelated edits that shift lines, and is hence suitable
+// for identifying the conflicts on this site across runs.
+func (s *primitiveSite) identity() string {
+	deepStr := ""
+	if s.IsDeep {
+		deepStr = "Deep "
+	}
+	return s.PkgPath + "." + string(s.ObjectPath) + ": " + deepStr + s.Repr
+}
+
 // primitivizer is able to convert full triggers and annotation sites to their primitive forms. It
 // is useful for getting the correct primitive sites and positions for upstream objects due to the
 // lack of complete position information in downstream analysis in incremental build systems (e.g.,
@@ -197,7 +211,18 @@ func (p *primitivizer) fullTrigger(trigger annotation.FullTrigger) primitiveFull
 
 // site returns the primitive version of the annotation site.
 func (p *primitivizer) site(key annotation.Key, isDeep bool) primitiveSite {
-	objPath, err := p.objPathEncoder.For(key.Object())
+	obj := key.Object()
+	// The fields and methods of the instantiated generic types are distinct objects for every
+	// instantiation, but they share the site of their generic origins (whose nilability the
+	// instantiations inherit, see annotation.siteDefaults).
+	switch o := obj.(type) {
+	case *types.Var:
+		obj = o.Origin()
+	case *types.Func:
+		obj = o.Origin()
+	}
+
+	objPath, err := p.objPathEncoder.For(obj)
 	if err != nil {
 		// An error will occur when trying to get object path for unexported objects, in which case
 		// we simply assign an empty object path.
@@ -205,13 +230,13 @@ func (p *primitivizer) site(key annotation.Key, isDeep bool) primitiveSite {
 	}
 
 	pkgRepr := ""
-	if pkg := key.Object().Pkg(); pkg != nil {
+	if pkg := obj.Pkg(); pkg != nil {
 		pkgRepr = pkg.Path()
 	}
 
 	var position token.Position
 	// For upstream objects, we need to look up the local position cache for correct positions.
-	if key.Object().Pkg() != p.pass.Pkg {
+	if obj.Pkg() != p.pass.Pkg {
 		// Correct upstream information may not always be in the cache: we may not even have it
 		// since we skipped analysis for standard and 3rd party libraries.
 		if p, ok := p.upstreamObjPositions[pkgRepr+"."+string(objPath)]; ok {
@@ -223,14 +248,14 @@ func (p *primitivizer) site(key annotation.Key, isDeep bool) primitiveSite {
 	// their Object.Pos() and retrieve the position information. However, we must trim the possible
 	// build-system sandbox prefix from the filenames for cross-package references.
 	if !position.IsValid() {
-		position = p.toPosition(key.Object().Pos())
+		position = p.toPosition(obj.Pos())
 	}
 
 	return primitiveSite{
 		PkgPath:    pkgRepr,
 		Repr:       key.String(),
 		IsDeep:     isDeep,
-		Exported:   key.Object().Exported(),
+		Exported:   obj.Exported(),
 		ObjectPath: objPath,
 		Position:   position,
 	}
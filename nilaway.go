@@ -38,12 +38,13 @@ var Analyzer = &analysis.Analyzer{
 
 func run(pass *analysis.Pass) (interface{}, error) {
 	conf := pass.ResultOf[config.Analyzer].(*config.Config)
-	deferredErrors := pass.ResultOf[accumulation.Analyzer].([]analysis.Diagnostic)
+	deferredErrors := pass.ResultOf[accumulation.Analyzer].(*accumulation.Result).Diagnostics
 	for _, e := range deferredErrors {
+		d := e.Diagnostic
 		if conf.PrettyPrint {
-			e.Message = util.PrettyPrintErrorMessage(e.Message)
+			d.Message = util.PrettyPrintErrorMessage(d.Message)
 		}
-		pass.Report(e)
+		pass.Report(d)
 	}
 
 	return nil, nil
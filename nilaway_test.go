@@ -17,11 +17,16 @@ package nilaway
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"go.uber.org/nilaway/accumulation"
 	"go.uber.org/nilaway/config"
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
 )
 
@@ -47,7 +52,7 @@ func TestNilAway(t *testing.T) {
 		{name: "Channels", patterns: []string{"go.uber.org/channels"}},
 		{name: "GoQuirks", patterns: []string{"go.uber.org/goquirks"}},
 		{name: "GlobalVars", patterns: []string{"go.uber.org/globalvars"}},
-		{name: "DeepNil", patterns: []string{"go.uber.org/deepnil", "go.uber.org/deepnil/inference"}},
+		{name: "DeepNil", patterns: []string{"go.uber.org/deepnil", "go.uber.org/deepnil/inference", "go.uber.org/deepnil/multilevel", "go.uber.org/deepnil/multilevel/inference"}},
 		{name: "NilableTypes", patterns: []string{"go.uber.org/nilabletypes"}},
 		{name: "HelloWorld", patterns: []string{"go.uber.org/helloworld"}},
 		{name: "MultiFilePackage", patterns: []string{"go.uber.org/multifilepackage", "go.uber.org/multifilepackage/firstpackage", "go.uber.org/multifilepackage/secondpackage"}},
@@ -61,12 +66,16 @@ func TestNilAway(t *testing.T) {
 		{name: "IgnoreGenerated", patterns: []string{"go.uber.org/ignoregenerated"}},
 		{name: "IgnorePackage", patterns: []string{"ignoredpkg1", "ignoredpkg2"}},
 		{name: "Receivers", patterns: []string{"go.uber.org/receivers", "go.uber.org/receivers/inference"}},
-		{name: "Generics", patterns: []string{"go.uber.org/generics"}},
+		{name: "Generics", patterns: []string{"go.uber.org/generics", "go.uber.org/generics/inference"}},
 		{name: "FunctionContracts", patterns: []string{"go.uber.org/functioncontracts", "go.uber.org/functioncontracts/inference"}},
 		{name: "Constants", patterns: []string{"go.uber.org/consts"}},
 		{name: "ErrorMessage", patterns: []string{"go.uber.org/errormessage", "go.uber.org/errormessage/inference"}},
 		{name: "LoopRange", patterns: []string{"go.uber.org/looprange"}},
 		{name: "AbnormalFlow", patterns: []string{"go.uber.org/abnormalflow"}},
+		{name: "StdlibModel", patterns: []string{"go.uber.org/stdlibmodel"}},
+		{name: "StructTags", patterns: []string{"go.uber.org/structtags"}},
+		{name: "LocalVars", patterns: []string{"go.uber.org/localvars"}},
+		{name: "DefaultDirective", patterns: []string{"go.uber.org/defaultdirective"}},
 	}
 
 	for _, tt := range tests {
@@ -91,7 +100,7 @@ func TestStructInit(t *testing.T) { //nolint:paralleltest
 	}()
 
 	testdata := analysistest.TestData()
-	analysistest.Run(t, testdata, Analyzer, "go.uber.org/structinit/funcreturnfields", "go.uber.org/structinit/local", "go.uber.org/structinit/global", "go.uber.org/structinit/paramfield", "go.uber.org/structinit/paramsideeffect", "go.uber.org/structinit/defaultfield")
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/structinit/funcreturnfields", "go.uber.org/structinit/local", "go.uber.org/structinit/global", "go.uber.org/structinit/paramfield", "go.uber.org/structinit/paramsideeffect", "go.uber.org/structinit/defaultfield", "go.uber.org/structinit/noinfer")
 }
 
 func TestAnonymousFunction(t *testing.T) { //nolint:paralleltest
@@ -108,6 +117,20 @@ func TestAnonymousFunction(t *testing.T) { //nolint:paralleltest
 	analysistest.Run(t, testdata, Analyzer, "go.uber.org/anonymousfunction")
 }
 
+func TestMultiContractInference(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel since we need to enable the
+	// experimental contract inference for multiple parameters or results to test this feature.
+	err := config.Analyzer.Flags.Set(config.ExperimentalMultiContractInferenceFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.ExperimentalMultiContractInferenceFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "go.uber.org/functioncontracts/inference/multiple")
+}
+
 func TestPrettyPrint(t *testing.T) { //nolint:paralleltest
 	// We specifically do not set this test to be parallel such that this test is run separately
 	// from the parallel tests. This makes it possible to set the pretty-print flag to true for
@@ -123,6 +146,197 @@ func TestPrettyPrint(t *testing.T) { //nolint:paralleltest
 	analysistest.Run(t, testdata, Analyzer, "prettyprint")
 }
 
+func TestConciseMessages(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the concise-messages flag to true for
+	// testing and false for the other tests.
+	err := config.Analyzer.Flags.Set(config.ConciseMessagesFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.ConciseMessagesFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "concisemessages")
+
+	// The complete nil flow should be attached to the diagnostic as related information.
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	d := results[0].Diagnostics[0]
+	var related []string
+	for _, r := range d.Related {
+		related = append(related, fmt.Sprintf("%d: %s", results[0].Pass.Fset.Position(r.Pos).Line, r.Message))
+	}
+	require.Equal(t, []string{
+		"5: literal `nil` returned from `retNil()` in position 0",
+		"11: result 0 of `retNil()` dereferenced",
+	}, related)
+}
+
+func TestFingerprints(t *testing.T) {
+	t.Parallel()
+
+	// The same package is analyzed before and after unrelated edits that shift lines, and the IDs
+	// of the diagnostics should stay the same.
+	ids := func(dir string) []string {
+		results := analysistest.Run(t, filepath.Join(analysistest.TestData(), "fingerprint", dir), Analyzer, "fingerprint")
+		require.Len(t, results, 1)
+		var ids []string
+		for _, d := range results[0].Diagnostics {
+			m := regexp.MustCompile(`\[ID: ([0-9a-f]{16})\]`).FindStringSubmatch(d.Message)
+			require.NotNil(t, m, d.Message)
+			ids = append(ids, m[1])
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	before, after := ids("before"), ids("after")
+	require.Len(t, before, 2)
+	require.NotEqual(t, before[0], before[1])
+	require.Equal(t, before, after)
+}
+
+func TestContractDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	// The diagnostics on the function contracts are not generated from the nil flows, but they
+	// should still be categorized and fingerprinted like the others.
+	results := analysistest.Run(t, analysistest.TestData(), accumulation.Analyzer, "contractdiagnostics")
+	require.Len(t, results, 1)
+	diagnostics := results[0].Result.(*accumulation.Result).Diagnostics
+	require.Len(t, diagnostics, 1)
+	require.Contains(t, diagnostics[0].Message, "violates its contract")
+	require.Equal(t, config.CategoryContract, diagnostics[0].Category)
+	require.Regexp(t, "^[0-9a-f]{16}$", diagnostics[0].Fingerprint)
+}
+
+func TestIgnoreDirectives(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the check-ignore-directives flag to
+	// true for testing and false for the other tests.
+	err := config.Analyzer.Flags.Set(config.CheckIgnoreDirectivesFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.CheckIgnoreDirectivesFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "ignoredirective")
+}
+
+func TestAnnotationLint(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the lint-annotations flag to true for
+	// testing and false for the other tests.
+	err := config.Analyzer.Flags.Set(config.LintAnnotationsFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.LintAnnotationsFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "annotationlint")
+}
+
+func TestCategories(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the category flags for testing without
+	// affecting the other tests.
+	err := config.Analyzer.Flags.Set(config.EnableCategoriesFlag, "map-write,dereference")
+	require.NoError(t, err)
+	err = config.Analyzer.Flags.Set(config.DisableCategoriesFlag, "dereference")
+	require.NoError(t, err)
+	err = config.Analyzer.Flags.Set(config.CheckIgnoreDirectivesFlag, "true")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.EnableCategoriesFlag, "")
+		require.NoError(t, err)
+		err = config.Analyzer.Flags.Set(config.DisableCategoriesFlag, "")
+		require.NoError(t, err)
+		err = config.Analyzer.Flags.Set(config.CheckIgnoreDirectivesFlag, "false")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	results := analysistest.Run(t, testdata, Analyzer, "categories")
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Diagnostics, 1)
+	require.Equal(t, "map-write", results[0].Diagnostics[0].Category)
+}
+
+func TestCategoryFlags(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the category flags for testing without
+	// affecting the other tests.
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.EnableCategoriesFlag, "")
+		require.NoError(t, err)
+	}()
+
+	// The spaces around the categories are trimmed.
+	err := config.Analyzer.Flags.Set(config.EnableCategoriesFlag, "map-write, dereference")
+	require.NoError(t, err)
+	res, err := config.Analyzer.Run(&analysis.Pass{Analyzer: config.Analyzer})
+	require.NoError(t, err)
+	require.True(t, res.(*config.Config).IsCategoryEnabled(config.CategoryDereference))
+	require.False(t, res.(*config.Config).IsCategoryEnabled(config.CategoryFieldAccess))
+
+	// The unknown categories are reported instead of silently suppressing all diagnostics.
+	err = config.Analyzer.Flags.Set(config.EnableCategoriesFlag, "derefs")
+	require.NoError(t, err)
+	_, err = config.Analyzer.Run(&analysis.Pass{Analyzer: config.Analyzer})
+	require.ErrorContains(t, err, `unknown diagnostic category "derefs" in -enable-categories`)
+}
+
+func TestStubFiles(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to set the stub files and exclude the
+	// upstream package without affecting the other tests.
+	testdata := analysistest.TestData()
+	err := config.Analyzer.Flags.Set(config.StubFilesFlag, filepath.Join(testdata, "src", "stubs", "stubs.yaml"))
+	require.NoError(t, err)
+	err = config.Analyzer.Flags.Set(config.ExcludePkgsFlag, "ignoredpkg1,ignoredpkg2,stubs/upstream")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.StubFilesFlag, "")
+		require.NoError(t, err)
+		err = config.Analyzer.Flags.Set(config.ExcludePkgsFlag, "ignoredpkg1,ignoredpkg2")
+		require.NoError(t, err)
+	}()
+
+	analysistest.Run(t, testdata, Analyzer, "stubs")
+}
+
+func TestStdlibModelDisabled(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to disable the standard library model for
+	// testing without affecting the other tests. The standard library packages are excluded from
+	// analysis to mimic the typical setups.
+	err := config.Analyzer.Flags.Set(config.StdlibModelFlag, "false")
+	require.NoError(t, err)
+	err = config.Analyzer.Flags.Set(config.IncludePkgsFlag, "stdlibmodeldisabled")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.StdlibModelFlag, "true")
+		require.NoError(t, err)
+		err = config.Analyzer.Flags.Set(config.IncludePkgsFlag, "")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "stdlibmodeldisabled")
+}
+
+func TestSuggestedFixes(t *testing.T) {
+	t.Parallel()
+
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "suggestedfix")
+}
+
 func TestGroupErrorMessages(t *testing.T) { //nolint:paralleltest
 	// We specifically do not set this test to be parallel such that this test is run separately
 	// from the parallel tests. This makes it possible to test the group error messages flag independently
@@ -146,6 +360,25 @@ func TestGroupErrorMessages(t *testing.T) { //nolint:paralleltest
 	}()
 }
 
+func TestDefaultTypes(t *testing.T) { //nolint:paralleltest
+	// We specifically do not set this test to be parallel such that this test is run separately
+	// from the parallel tests. This makes it possible to configure the default nilable and nonnil
+	// types for testing without affecting the other tests.
+	err := config.Analyzer.Flags.Set(config.DefaultNilableTypesFlag, "defaulttypes.Tx,defaulttypes.Conn,defaulttypes.Optional")
+	require.NoError(t, err)
+	err = config.Analyzer.Flags.Set(config.DefaultNonnilTypesFlag, "*defaulttypes.Logger")
+	require.NoError(t, err)
+	defer func() {
+		err := config.Analyzer.Flags.Set(config.DefaultNilableTypesFlag, "")
+		require.NoError(t, err)
+		err = config.Analyzer.Flags.Set(config.DefaultNonnilTypesFlag, "")
+		require.NoError(t, err)
+	}()
+
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, Analyzer, "defaulttypes")
+}
+
 func TestMain(m *testing.M) {
 	flags := map[string]string{
 		// Pretty print should be turned off for easier error message matching in test files.
@@ -0,0 +1,37 @@
+// Package fingerprint is meant to check that the IDs of the diagnostics are stable across
+// unrelated edits that shift lines (see the same package under the "before" directory).
+package fingerprint
+
+import "fmt"
+
+// test is moved before the other functions, with unrelated code added.
+func test() {
+	fmt.Println("unrelated")
+
+	print(*retNil()) //want `\[ID: [0-9a-f]{16}\]`
+}
+
+func retNil() *int {
+	// Unrelated comments.
+	return nil
+}
+
+// S is a struct with an unrelated method added.
+type S struct {
+	f *int
+}
+
+func (s *S) unrelated() bool {
+	return s != nil
+}
+
+func (s *S) reset() {
+	s.f = nil
+}
+
+func (s *S) deref() int {
+	if s.unrelated() {
+		print("unrelated")
+	}
+	return *s.f //want `\[ID: [0-9a-f]{16}\]`
+}
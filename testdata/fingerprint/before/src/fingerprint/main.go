@@ -0,0 +1,23 @@
+// Package fingerprint is meant to check that the IDs of the diagnostics are stable across
+// unrelated edits that shift lines (see the same package under the "after" directory).
+package fingerprint
+
+type S struct {
+	f *int
+}
+
+func retNil() *int {
+	return nil
+}
+
+func (s *S) reset() {
+	s.f = nil
+}
+
+func (s *S) deref() int {
+	return *s.f //want `\[ID: [0-9a-f]{16}\]`
+}
+
+func test() {
+	print(*retNil()) //want `\[ID: [0-9a-f]{16}\]`
+}
@@ -0,0 +1,109 @@
+// Package annotationlint tests the annotation linter, which reports the nilability annotations
+// that are malformed or have no effect.
+package annotationlint
+
+// nilable(reuslt 0) // want "malformed nilability annotation"
+func typoResult() *int { return nil }
+
+// nilable(reuslt) // want "unknown name .reuslt. in nilability annotation on function .typoName."
+func typoName() (result *int) { return nil }
+
+// nilable(paramm) // want "unknown name .paramm."
+func typoParam(param *int) {}
+
+// nilable(result 1) // want "out of range, function .outOfRange. has 1 result"
+func outOfRange() *int { return nil }
+
+// nilable(param 0) // want "has no effect since the parameter is named, use .x. instead"
+func namedParam(x *int) {}
+
+// nilable(param 1, result 0, *result 0)
+func unnamed(*int, *int) **int { return nil }
+
+// nilable(x) nonnil(x) // want "conflicting nilability annotations on .x."
+func conflicting(x *int) {}
+
+// nilable(x) // want "has no effect since its type .int. can never be nil"
+func barsNilness(x int) {}
+
+// nilable(x[]) // want "deep nilability annotation on .x. has no effect"
+func deepBarsNilness(x []int) {}
+
+// nilable(x[][]) // want "depth 2 nilability annotation on .x. has no effect"
+func deeperBarsNilness(x [][]int) {}
+
+// nilable(x[][], **p) nonnil(x[], x[][]) // want "conflicting depth 2 nilability annotations on .x."
+func deeper(x [][]*int, p ***int) {}
+
+// nilable(x[], y, s)
+func (s *S) valid(x []*int, y *int) {}
+
+// nonnil(result 0 // want "malformed nilability annotation"
+func malformed() *int { return new(int) }
+
+// nilable(f, g) // want "unknown name .g. in nilability annotation on type .S."
+type S struct {
+	f *int
+	// nilable(h) // want "not attached to any declaration"
+	h *int
+}
+
+// nilable(v) // want "can never be nil"
+var v S
+
+// I is an interface.
+type I interface {
+	// nilable(result 0, err2) // want "unknown name .err2. in nilability annotation on method .Foo."
+	Foo() (*int, error)
+}
+
+func takes(x *int) {}
+
+func caller() {
+	takes(nil) // nilable(param 0)
+	takes(nil) // nilable(param 1) // want "out of range, call to .takes. has 1 parameter"
+}
+
+// T tests the annotations in struct tags.
+type T struct {
+	a *int   `nilaway:"nilable"`
+	b []*int `json:"b" nilaway:"nonnil,deepnilable"`
+	c *int   `nilaway:"nilabel"`        // want "unknown value .nilabel. in .nilaway. struct tag"
+	d int    `nilaway:"nilable"`        // want "nilability annotation on .d. has no effect since its type .int. can never be nil"
+	e *int   `nilaway:"nilable,nonnil"` // want "conflicting nilability annotations on .e."
+}
+
+func ret() *int { return nil }
+
+func locals() {
+	// nilable(x[])
+	x := make([]*int, 1)
+	var y []*int // nilable(y[], z) // want "unknown name .z. in nilability annotation on local variable declaration"
+	// nilable(n) // want "nilability annotation on .n. has no effect since its type .int. can never be nil"
+	n := 1
+	p := ret() // nilable(result 0, p) // want "nilability annotation on local variable .p. has no effect since only the nilability of its elements can be annotated"
+	// nonnil(q, q[]) // want "nilability annotation on local variable .q. has no effect"
+	q := []*int{p}
+	print(x, y, n, p, q)
+}
+
+func suppressed() {
+	//nilaway:ignore the annotation documents the intent even though it has no effect
+	// nilable(n)
+	n := 1
+	print(n)
+}
+
+// nilable(T, U) // want "unknown name .U. in nilability annotation on type .Box."
+type Box[T any] struct {
+	val T
+}
+
+// nonnil(T, *T) // want "deep nilability annotation on .T. has no effect"
+func (b *Box[T]) get() T { return b.val }
+
+// nilable(V) // want "unknown name .V. in nilability annotation on function .renamed."
+func (b *Box[T]) renamed() {}
+
+// nilable(E)
+func first[E any](s []E) E { return s[0] }
@@ -0,0 +1,5 @@
+/* want "unknown default nilability in .// nilaway:default\\(maybe\\)." */ // nilaway:default(maybe)
+
+package annotationlint
+
+/* want "default nilability directive is not before the package clause and has no effect" */ // nilaway:default(nonnil)
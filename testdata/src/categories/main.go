@@ -0,0 +1,25 @@
+// Package categories is meant to check if our enable-categories and disable-categories flags have
+// effect. The test enables "map-write" and "dereference", and then disables "dereference", so only
+// the map writes should be reported. The ignore directives covering the diagnostics of the disabled
+// categories are still considered used.
+package categories
+
+type S struct {
+	f int
+}
+
+func test() {
+	var m map[int]int
+	m[0] = 1 //want "written to at an index"
+
+	var x *int
+	print(*x)
+
+	var s *S
+	print(s.f)
+}
+
+func suppressed() {
+	var x *int
+	print(*x) //nilaway:ignore the pointer is always set by the caller
+}
@@ -0,0 +1,12 @@
+// Package concisemessages is meant to check if our concise-messages flag has effect.
+package concisemessages
+
+func retNil() *int {
+	return nil
+}
+
+func test() {
+	// The concise message should only contain the source and the dereference point of the nil
+	// flow (see the test for the complete flow in the related information).
+	print(*retNil()) //want "^Potential nil panic detected: literal `nil` returned from `retNil.*` in position 0 -> result 0 of `retNil.*` dereferenced \\[ID: [0-9a-f]{16}\\]$"
+}
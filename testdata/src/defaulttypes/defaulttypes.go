@@ -0,0 +1,90 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package defaulttypes tests the named types configured to be nilable (Tx, Conn and Optional) or
+// nonnil (Logger) by default, which are honored by the inference as well unless annotated
+// otherwise.
+package defaulttypes
+
+type Tx struct {
+	id int
+}
+
+type Conn interface {
+	Close()
+}
+
+type Optional[T any] struct {
+	v T
+}
+
+type Logger struct {
+	name string
+}
+
+func useTx(tx *Tx) int {
+	return tx.id //want "accessed field"
+}
+
+func useCheckedTx(tx *Tx) int {
+	if tx != nil {
+		return tx.id
+	}
+	return 0
+}
+
+// nonnil(tx)
+func useAnnotatedTx(tx *Tx) int { //want "passed"
+	return tx.id
+}
+
+func useConn(c Conn) {
+	c.Close() //want "called"
+}
+
+func useOptional(o *Optional[int]) int {
+	return o.v //want "accessed field"
+}
+
+func useOtherOptional(o *Optional[string]) string {
+	return o.v //want "accessed field"
+}
+
+func useTxs(txs map[string]*Tx) int {
+	return txs["key"].id //want "accessed field"
+}
+
+type Service struct {
+	log *Logger //want "assigned"
+}
+
+func (s *Service) name() string {
+	return s.log.name
+}
+
+func resetService(s *Service) {
+	s.log = nil
+}
+
+func callers() {
+	useTx(nil)
+	useAnnotatedTx(nil)
+}
+
+// The elements written through the dereferenced pointers are not annotation sites, hence their
+// deep nilability is determined by the element types alone, which honors the configured types.
+func deepWrites(txs *[]*Tx, loggers *[]*Logger) {
+	(*txs)[0] = nil
+	(*loggers)[0] = nil //want "assigned into a deep type"
+}
@@ -0,0 +1,55 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This package aims to test the multi-level deep nilability in the inference mode.
+
+package inference
+
+func setNilNested(x [][]*int) [][]*int {
+	x[0][0] = nil
+	return x
+}
+
+func readSetNilNested(x [][]*int) int {
+	return *setNilNested(x)[0][0] //want "deep read from the elements at depth 2 of result 0 of `setNilNested"
+}
+
+func passNilNested(x [][]*int) {
+	x[0][0] = nil
+	readNested(x)
+}
+
+func readNested(x [][]*int) int {
+	return *x[0][0] //want "deep read from the elements at depth 2 of parameter `x`"
+}
+
+func setNonnilNested(x [][]*int) [][]*int {
+	i := 0
+	x[0][0] = &i
+	return x
+}
+
+func readSetNonnilNested(x [][]*int) int {
+	return *setNonnilNested(x)[0][0]
+}
+
+var nestedGlobal [][]*int = [][]*int{{new(int)}}
+
+func setNilGlobal() {
+	nestedGlobal[0][0] = nil
+}
+
+func readGlobal() int {
+	return *nestedGlobal[0][0] //want "deep read from the elements at depth 2 of global variable `nestedGlobal`"
+}
@@ -0,0 +1,118 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This package aims to test the multi-level deep nilability annotations, e.g., `nilable(x[][])` for
+the elements of the slices in `x`, and `nilable(**p)` for the values pointed to by `*p`.
+
+<nilaway no inference>
+*/
+package multilevel
+
+// Slices (including the ones in slices) are nilable by default, hence they are annotated as
+// nonnil here to focus on the pointers nested in them.
+
+// nonnil(x, x[]) nilable(x[][])
+func readNilableNested(x [][]*int) int {
+	return *x[0][0] //want "deep read from the elements at depth 2 of parameter `x` dereferenced"
+}
+
+// nonnil(x, x[])
+func readNonnilNested(x [][]*int) int {
+	return *x[0][0]
+}
+
+// nonnil(x, x[]) nilable(x[][])
+func readCheckedNested(x [][]*int, i int) int {
+	if x[i][i] != nil {
+		return *x[i][i]
+	}
+	return 0
+}
+
+// nilable(**p)
+func readUncheckedPtrPtr(p ***int) int {
+	return ***p //want "deep read from the elements at depth 2 of parameter `p` dereferenced"
+}
+
+// nilable(m[][])
+func readNilableNestedMap(m map[string]map[string]*int) int {
+	if v, ok := m["a"]["b"]; ok {
+		return *v //want "deep read from the elements at depth 2 of parameter `m`"
+	}
+	return 0
+}
+
+// nonnil(x, x[])
+func assignNilNested(x [][]*int) {
+	x[0][0] = nil //want "assigned deeply into the elements at depth 2 of parameter `x`"
+}
+
+// nonnil(x, x[]) nilable(x[][])
+func assignNilNilableNested(x [][]*int) {
+	x[0][0] = nil
+}
+
+// The deep annotation only applies to the slices in `x`.
+// nonnil(x) nilable(x[])
+func assignNilOnlyDeep(x [][]*int) {
+	x[0] = nil
+	if x[1] != nil {
+		x[1][0] = nil //want "assigned deeply into the elements at depth 2 of parameter `x`"
+	}
+}
+
+// nonnil(x, x[]) nilable(x[][])
+func passNilableNested(x [][]*int) {
+	takesNonnilNested(x) //want "passed as arg `x` to `takesNonnilNested"
+}
+
+func takesNonnilNested(x [][]*int) {}
+
+// nonnil(result 0, result 0[]) nilable(result 0[][])
+func retNilableNested() [][]*int {
+	return [][]*int{{nil}}
+}
+
+func readRetNilableNested() int {
+	return *retNilableNested()[0][0] //want "deep read from the elements at depth 2 of result 0 of `retNilableNested"
+}
+
+// nonnil(x, x[]) nilable(x[][])
+func returnNilableNested(x [][]*int) [][]*int {
+	return x //want "returned deeply from `returnNilableNested"
+}
+
+// nonnil(nestedGlobal, nestedGlobal[])
+var nestedGlobal [][]*int = [][]*int{{new(int)}}
+
+func assignNilNestedGlobal() {
+	nestedGlobal[0][0] = nil //want "assigned deeply into the elements at depth 2 of global variable `nestedGlobal`"
+}
+
+// nonnil(f, f[]) nilable(f[][][])
+type S struct {
+	f [][][]*int
+}
+
+func readNilableField(s *S) int {
+	return *s.f[0][0][0] //want "deep read from the elements at depth 3 of field `f`"
+}
+
+// Multi-dimensional arrays are flattened, i.e., the slices in the arrays in `a` are at depth 1,
+// and the pointers in them are at depth 2.
+// nonnil(a, a[]) nilable(a[][])
+func readNilableArrays(a [][2][]*int) int {
+	return *a[0][1][2] //want "deep read from the elements at depth 2 of parameter `a`"
+}
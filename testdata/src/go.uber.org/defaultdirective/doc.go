@@ -0,0 +1,21 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package defaultdirective tests the default nilability directives: the directive in this package
+// doc makes all unannotated sites in the package nonnil (except the default nilable types such as
+// slices and errors), while the file-level directive in lenient.go makes the sites in that file
+// nilable instead.
+//
+// nilaway:default(nonnil)
+package defaultdirective
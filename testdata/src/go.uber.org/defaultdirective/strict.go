@@ -0,0 +1,50 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defaultdirective
+
+type T struct {
+	f *int //want "assigned"
+}
+
+func retNil() *int { //want "returned"
+	return nil
+}
+
+func retNilSlice() []*int {
+	return nil
+}
+
+func retNilErr() error {
+	return nil
+}
+
+// nilable(result 0)
+func retNilAnnotated() *int {
+	return nil
+}
+
+func setField(t *T) {
+	t.f = nil
+}
+
+func derefParam(p *int) int { //want "passed"
+	return *p
+}
+
+func callers() {
+	derefParam(nil)
+	print(*retNilAnnotated()) //want "dereferenced"
+	print(*retLenient())      //want "dereferenced"
+}
@@ -0,0 +1,85 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functioncontracts
+
+// Test the disjunctive contracts make the functions with non-standard error or boolean results
+// guarded by them, just like the ones returning `error` or `bool`.
+
+type myErr struct {
+	msg string
+}
+
+type found bool
+
+// nilable(result 1)
+// contract(_ -> nonnil, nil) | contract(_ -> nil, nonnil)
+func lookupOrErr(key string) (*int, *myErr) {
+	if key == "" {
+		return nil, &myErr{msg: "empty key"}
+	}
+	return new(int), nil
+}
+
+// contract(_ -> nonnil, true) | contract(_ -> nil, false)
+func lookupOrNotFound(key string) (*int, found) {
+	if key == "" {
+		return nil, false
+	}
+	return new(int), true
+}
+
+func useLookupOrErr(key string) int {
+	v, err := lookupOrErr(key)
+	if err != nil {
+		return 0
+	}
+	return *v
+}
+
+func useLookupOrErrUnchecked(key string) int {
+	v, _ := lookupOrErr(key)
+	return *v // want "dereferenced"
+}
+
+func useLookupOrNotFound(key string) int {
+	v, ok := lookupOrNotFound(key)
+	if !ok {
+		return 0
+	}
+	return *v
+}
+
+func useLookupOrNotFoundUnchecked(key string) int {
+	v, _ := lookupOrNotFound(key)
+	return *v // want "dereferenced"
+}
+
+// nilable(result 0, result 1)
+// contract(_ -> nonnil, nil) | contract(_ -> nil, nonnil)
+func violatedDisjunction(key string) (*int, *myErr) {
+	if key == "" {
+		return nil, nil // want "function `violatedDisjunction..` violates its contract `contract._ -> nonnil, nil. | contract._ -> nil, nonnil.` by returning nil in position 0 and nil in position 1 when `key == \"\"` is true"
+	}
+	return new(int), nil
+}
+
+// nilable(x, result 0)
+// contract(nil -> nil)
+func violatedNil(x *int) *int {
+	if x == nil {
+		return new(int) // want "function `violatedNil..` violates its contract `contract.nil -> nil.` by returning nonnil in position 0 when `x == nil` is true"
+	}
+	return x
+}
@@ -0,0 +1,51 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This package aims to test the experimental contract inference for functions with multiple
+// parameters or results in full inference mode.
+
+package multiple
+
+import "math/rand"
+
+// Test the contract inferred for the second parameter of a function with multiple parameters and
+// results, i.e., contract(_, nonnil -> _, nonnil).
+func fooMultiple(x *int, y *int) (int, *int) {
+	if y != nil {
+		return *x, new(int) // want "function parameter `x` .* dereferenced"
+	}
+	if rand.Float64() > 0.5 {
+		return 0, new(int)
+	}
+	return 0, nil
+}
+
+func barMultiple1() {
+	n := 1
+	_, b1 := fooMultiple(&n, &n)
+	print(*b1) // No error due to the contract.
+}
+
+func barMultiple2() {
+	n := 1
+	var y *int
+	_, b2 := fooMultiple(&n, y)
+	print(*b2) // want "result 1 of `fooMultiple.*` .* dereferenced"
+}
+
+func barMultiple3() {
+	n := 1
+	_, b3 := fooMultiple(nil, &n)
+	print(*b3) // No error due to the contract.
+}
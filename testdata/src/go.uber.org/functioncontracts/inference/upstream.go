@@ -0,0 +1,39 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inference
+
+import "go.uber.org/functioncontracts/upstream"
+
+// Test the contracts of the upstream functions, which are imported via facts, are instantiated at
+// the call sites.
+func callUpstreamPtr() {
+	n := 1
+	print(*upstream.Ptr(&n)) // No error due to the contract.
+	var b *int
+	print(*upstream.Ptr(b)) // want "result 0 of `Ptr.*` .* dereferenced"
+}
+
+func callUpstreamLookup(m map[string]*int) {
+	n := 1
+	print(*upstream.Lookup(m, "a", &n)) // No error due to the contract.
+	print(*upstream.Lookup(m, "a", nil)) // want "result 0 of `Lookup.*` .* dereferenced"
+}
+
+func callUpstreamPair() {
+	n := 1
+	x, y := upstream.Pair(&n)
+	print(*x) // No error due to the contract.
+	print(*y) // want "result 1 of `Pair.*` .* dereferenced"
+}
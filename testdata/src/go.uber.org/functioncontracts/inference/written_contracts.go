@@ -18,13 +18,12 @@ package inference
 
 import "math/rand"
 
-// We set an incorrect manual contract here, but NilAway should still respect it.
-// TODO: NilAway should validate and warn users of incorrect manual contract annotations.
+// We set an incorrect manual contract here, NilAway should warn about it but still respect it.
 // contract(nonnil -> nonnil)
 func incorrectContract(x *int) *int {
 	if x != nil {
 		// Returns nil if the input is nonnil, violating the contract.
-		return nil
+		return nil // want "function `incorrectContract..` violates its contract `contract.nonnil -> nonnil.` by returning nil in position 0 when `x != nil` is true"
 	}
 	// Return nonnil or nil randomly
 	if rand.Float64() > 0.5 {
@@ -68,3 +67,26 @@ func barUnnamedParam2() {
 	b2 := fooUnnamedParam(a2)
 	print(*b2) // No error here.
 }
+
+// The nil argument flows to the nil result at the call sites, while the nonnil argument makes the
+// result nonnil.
+// contract(nil -> nil)
+// contract(nonnil -> nonnil)
+func nilToNil(x *int) *int {
+	if x == nil {
+		return nil
+	}
+	v := *x
+	return &v
+}
+
+func useNilToNil1() {
+	n := 1
+	print(*nilToNil(&n)) // No error here due to the contracts.
+}
+
+func useNilToNil2() {
+	var x *int
+	// Both the literal nil in the body and the nil argument flow to the result.
+	print(*nilToNil(x)) // want "literal `nil` returned from `nilToNil..`" "function parameter `x` .* returned from `nilToNil..`"
+}
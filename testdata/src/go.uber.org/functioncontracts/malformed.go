@@ -0,0 +1,99 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functioncontracts
+
+// Test the malformed or invalid handwritten contracts are reported at the comments, and the valid
+// ones are still read.
+
+// want +1 "unknown value `nonnul` in function contract `contract.nonnul -> nonnil.` on function `unknownValue`"
+// contract(nonnul -> nonnil)
+func unknownValue(x *int) *int {
+	return x
+}
+
+// want +1 "malformed function contract `contract.nonnil - > nonnil.` on function `missingArrow`"
+// contract(nonnil - > nonnil)
+func missingArrow(x *int) *int {
+	return x
+}
+
+// want +1 "missing value in function contract `contract.nonnil, -> nonnil.` on function `missingValue`"
+// contract(nonnil, -> nonnil)
+func missingValue(x *int) *int {
+	return x
+}
+
+// want +1 "unexpected text `and` in function contracts on function `unexpectedText`"
+// contract(nonnil -> nonnil) and contract(_ -> _)
+func unexpectedText(x *int) *int {
+	return x
+}
+
+// want +1 "function contract `contract.nonnil, _ -> nonnil.` has 2 parameter value.s. and 1 result value.s., but function `arityMismatch` has 1 parameter.s. and 1 result.s."
+// contract(nonnil, _ -> nonnil)
+func arityMismatch(x *int) *int {
+	return x
+}
+
+// want +1 "value `nonnil` for parameter 0 in function contract `contract.nonnil -> nonnil.` on function `nonNilableParam` has no effect since its type `int` can never be nil"
+// contract(nonnil -> nonnil)
+func nonNilableParam(i int) *int {
+	return new(int)
+}
+
+// want +1 "value `true` for result 0 in function contract `contract.nonnil -> true.` on function `nonBoolResult` requires a boolean result, but its type is `.int`"
+// contract(nonnil -> true)
+func nonBoolResult(x *int) *int {
+	return x
+}
+
+// want +1 "value `true` for parameter 0 in function contract `contract.true -> nonnil.` on function `boolParam` is not supported"
+// contract(true -> nonnil)
+func boolParam(b bool) *int {
+	return new(int)
+}
+
+// want +2 "duplicate function contract `contract.nonnil -> nonnil.` on function `duplicate`"
+// contract(nonnil -> nonnil)
+// contract(nonnil -> nonnil)
+func duplicate(x *int) *int {
+	return x
+}
+
+// want +2 "function contract `contract._, nonnil -> false.` contradicts `contract.nonnil, _ -> true.` on function `contradictory`, result 0 cannot be both `true` and `false`"
+// contract(nonnil, _ -> true)
+// contract(_, nonnil -> false)
+func contradictory(x *int, y *int) bool {
+	return x != nil || y != nil
+}
+
+// The contracts mentioned in the docs are not read, e.g.,
+// contract(nonnil -> nonnil) holds for this function.
+func mentioned(x *int) *int {
+	return x
+}
+
+// want +1 "value `nil` for result 0 in function contract `contract.nil -> nil.` on function `nilInt` has no effect since its type `int` can never be nil"
+// contract(nil -> nil)
+func nilInt(x *int) int {
+	return 0
+}
+
+// want +2 "function contract `contract.nonnil -> nil.` contradicts `contract.nonnil -> nonnil.` on function `contradictoryNil`, result 0 cannot be both `nonnil` and `nil`"
+// contract(nonnil -> nonnil)
+// contract(nonnil -> nil)
+func contradictoryNil(x *int) *int {
+	return x
+}
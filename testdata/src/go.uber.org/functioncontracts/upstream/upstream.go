@@ -0,0 +1,58 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upstream contains contracted functions that are called by the downstream packages, whose
+// contracts are exported as facts.
+package upstream
+
+import "math/rand"
+
+// Ptr returns a nonnil pointer if the given pointer is nonnil. The contract is inferred.
+func Ptr(x *int) *int {
+	if x != nil {
+		return x
+	}
+	if rand.Float64() > 0.5 {
+		return new(int)
+	}
+	return nil
+}
+
+// Lookup returns the value of the key in the map, or the default value if the key is absent.
+// contract(_, _, nonnil -> nonnil)
+func Lookup(m map[string]*int, key string, deft *int) *int {
+	if v, ok := m[key]; ok && v != nil {
+		return v
+	}
+	return deft
+}
+
+// Pair returns the given pointer along with another pointer that may be nil regardless of the
+// argument.
+// contract(nonnil -> nonnil, _)
+func Pair(x *int) (*int, *int) {
+	if x == nil {
+		return nil, nil
+	}
+	if rand.Float64() > 0.5 {
+		return x, new(int)
+	}
+	return x, nil
+}
+
+// unexported is not callable from the downstream packages, hence its contract is not exported.
+// contract(nonnil -> nonnil)
+func unexported(x *int) *int {
+	return x
+}
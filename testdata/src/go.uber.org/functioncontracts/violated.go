@@ -0,0 +1,71 @@
+//  Copyright (c) 2023 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package functioncontracts
+
+import "math/rand"
+
+// Test the handwritten contracts are verified against the function bodies.
+
+// nilable(x, result 0)
+// contract(nonnil -> nonnil)
+func violated(x *int) *int {
+	if x == nil {
+		return nil
+	}
+	if rand.Float64() > 0.5 {
+		return nil // want "function `violated..` violates its contract `contract.nonnil -> nonnil.` by returning nil in position 0 when `x == nil` is false and `rand.Float64.. > 0.5` is true"
+	}
+	return x
+}
+
+// nilable(x)
+// contract(nonnil -> true)
+func violatedBool(x *int) bool {
+	if x != nil {
+		return false // want "function `violatedBool..` violates its contract `contract.nonnil -> true.` by returning false in position 0 when `x != nil` is true"
+	}
+	return false
+}
+
+// nilable(m, deft, result 0)
+// contract(_, nonnil -> nonnil, true)
+func violatedSecondResult(m map[string]*int, deft *int) (*int, bool) {
+	if v := m["key"]; v != nil {
+		return v, true
+	}
+	return deft, false // want "function `violatedSecondResult..` violates its contract `contract._, nonnil -> nonnil, true.` by returning false in position 1 when `v != nil` is false"
+}
+
+// The contract cannot be proved by the analysis, but it is not violated either.
+// nilable(x, result 0)
+// contract(nonnil -> nonnil)
+func unproven(x *int) *int {
+	return identity(x)
+}
+
+// nilable(x, result 0)
+func identity(x *int) *int {
+	return x
+}
+
+// The contract holds, where the nil result is only returned for a nil argument.
+// nilable(x, result 0)
+// contract(nonnil -> nonnil)
+func holds(x *int) *int {
+	if x == nil {
+		return nil
+	}
+	return x
+}
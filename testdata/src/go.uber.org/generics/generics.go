@@ -12,10 +12,10 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// generics package tests NilAway's ability to handle generics introduced in Go 1.18.
-// Currently, we do not have support for generics yet, so this package simply tests that
-// NilAway should not panic when seeing ASTs related to generics.
-// TODO: Add support for generics.
+// generics package tests NilAway's ability to handle generics introduced in Go 1.18. This file
+// tests that NilAway should not panic when seeing ASTs related to generics, while the nilability
+// annotations on the type parameters are tested in typeparams.go.
+// TODO: Add support for the values of type parameters in the bodies of generic functions.
 //
 // <nilaway no inference>
 package generics
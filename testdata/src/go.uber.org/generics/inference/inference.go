@@ -0,0 +1,39 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inference tests that the instantiations of the generic types and functions share the
+// inferred nilability of their generic origins, both within the package and across packages.
+package inference
+
+import "go.uber.org/generics"
+
+type Cell[T any] struct {
+	val T
+}
+
+func reset(c *Cell[*int]) {
+	c.val = nil
+}
+
+func readCell(c *Cell[*string], d *Cell[int]) int {
+	return len(*c.val) + d.val // want "assigned into field `val`"
+}
+
+func readBox(b *generics.Box[*generics.Foo]) *generics.Foo {
+	return b.Get()
+}
+
+func readUpstream(b *generics.Box[*generics.Foo]) generics.Foo {
+	return *readBox(b) // want "result 0 of `Get..`"
+}
@@ -0,0 +1,132 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generics
+
+type Foo struct {
+	f int
+}
+
+// Box holds a value that may be absent, unless the box is instantiated with a type that is never
+// nil (e.g., `Box[Foo]`).
+// nilable(T)
+type Box[T any] struct {
+	val T
+}
+
+// Get returns the value of the box, whose result follows the annotation on the type parameter of
+// the box.
+func (b *Box[T]) Get() T {
+	return b.val
+}
+
+// Set sets the value of the box, where the type parameter of the receiver is renamed.
+func (b *Box[U]) Set(v U) {
+	b.val = v
+}
+
+func testBox(ptr *Box[*Foo], val *Box[Foo], i int) int {
+	switch i {
+	case 0:
+		return ptr.val.f // want "field `val` accessed field `f`"
+	case 1:
+		return val.val.f
+	case 2:
+		return ptr.Get().f // want "result 0 of `Get.."
+	case 3:
+		return val.Get().f
+	case 4:
+		if ptr.val != nil {
+			return ptr.val.f
+		}
+	case 5:
+		ptr.Set(nil)
+		ptr.val = nil
+	}
+	return 0
+}
+
+// Pair has no annotations on its type parameters, hence its fields are nonnil as usual.
+type Pair[K comparable, V any] struct {
+	key K
+	val V
+}
+
+func testPair(p *Pair[string, *Foo], q *Pair[*Foo, Foo]) int {
+	switch 0 {
+	case 1:
+		p.val = nil // want "assigned into field `val`"
+	case 2:
+		q.key = nil // want "assigned into field `key`"
+	}
+	return q.val.f
+}
+
+// List is annotated on the elements of its type parameter through the deep nilability of its
+// field.
+// nilable(T)
+// nonnil(items)
+type List[T any] struct {
+	items []T
+}
+
+func testList(l *List[*Foo], m *List[Foo]) int {
+	return l.items[0].f + m.items[0].f // want "deep read from field `items`"
+}
+
+// Lookup returns the value for the key, or the zero value if absent.
+// nilable(T)
+func Lookup[T any](m map[string]T, k string) T {
+	return m[k]
+}
+
+// MustLookup returns the value for the key, which must be present.
+// nonnil(T)
+func MustLookup[T any](m map[string]T, k string) T {
+	v, ok := m[k]
+	if !ok {
+		panic("absent")
+	}
+	return v
+}
+
+func testLookup(ptrs map[string]*Foo, vals map[string]Foo) int {
+	switch 0 {
+	case 1:
+		return Lookup(ptrs, "a").f // want "result 0 of `Lookup.."
+	case 2:
+		return Lookup(vals, "a").f
+	}
+	return MustLookup(ptrs, "a").f
+}
+
+// Clone returns a copy of the slice, whose type parameter is nilable by default since its
+// constraint only admits slices.
+func Clone[S ~[]E, E any](s S) S {
+	if s == nil {
+		return nil
+	}
+	return append(S{}, s...)
+}
+
+// Keys returns the keys of the map, whose type parameter has no core type and hence follows the
+// annotation.
+// nonnil(M)
+func Keys[M map[string]int | map[string]bool](m M) []string {
+	return nil
+}
+
+func testClone(s []*Foo) int {
+	return len(Keys(map[string]int{})) + Clone(s)[0].f // want "result 0 of `Clone..` sliced into"
+}
@@ -0,0 +1,67 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test checks that the annotations on the declarations of local variables (either directly above
+the declaring statements or at the end of their lines) are honored in no-infer mode.
+
+<nilaway no inference>
+*/
+package localvars
+
+func readAnnotated() {
+	// nilable(local[])
+	local := make([]*int, 1)
+	print(*local[0]) //want "deep read from local variable `local`"
+
+	var other = make([]*int, 1) // nilable(other[])
+	print(*other[0])            //want "deep read from local variable `other`"
+
+	m := map[string]*int{} // nilable(m[])
+	if v, ok := m["a"]; ok {
+		// The values themselves may be nil, which cannot be guarded by the ok check.
+		print(*v) //want "deep read from local variable `m`"
+	}
+}
+
+func writeAnnotated() {
+	// nonnil(local[])
+	local := make([]*int, 1)
+	local[0] = nil //want "assigned deeply into local variable `local`"
+
+	// nonnil(m[])
+	m := make(map[string]*int)
+	m["a"] = nil //want "assigned deeply into local variable `m`"
+
+	// nonnil(x[])
+	x, y := make([]*int, 1), make([]*int, 1)
+	x[0] = nil //want "assigned deeply into local variable `x`"
+	y[0] = nil
+}
+
+func unannotated() {
+	// The unannotated local variables are still treated optimistically.
+	local := make([]*int, 1)
+	print(*local[0])
+	m := make(map[string]*int)
+	m["a"] = nil
+}
+
+func shallowAnnotated() {
+	// The shallow annotations have no effect since the local variables themselves are tracked
+	// flow-sensitively, i.e., only the assigned values matter.
+	// nilable(p)
+	p := new(int)
+	print(*p)
+}
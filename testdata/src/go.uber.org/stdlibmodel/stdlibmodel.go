@@ -0,0 +1,39 @@
+// Package stdlibmodel tests the bundled nilability model of the Go standard library, which
+// declares the nilability of the standard library sites that NilAway does not analyze.
+package stdlibmodel
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"text/template"
+)
+
+func testFields(u *url.URL, r *http.Request) {
+	userinfo := *u.User //want "dereferenced"
+	if u.User != nil {
+		userinfo = *u.User
+	}
+	print(userinfo.Username())
+
+	print(r.URL.Path)
+	print(r.TLS.ServerName) //want "accessed field"
+}
+
+func testResults(err error, tmpl *template.Template, re *regexp.Regexp, m *sync.Map) {
+	print(errors.Unwrap(err).Error()) //want "called .Error"
+
+	print(tmpl.Lookup("foo").Tree) //want "accessed field"
+
+	matches := re.FindStringSubmatch("foo")
+	print(matches[1]) //want "sliced into"
+	if len(matches) > 1 {
+		print(matches[1])
+	}
+
+	if v, ok := m.Load("foo"); ok {
+		print(v)
+	}
+}
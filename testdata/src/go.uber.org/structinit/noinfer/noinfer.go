@@ -0,0 +1,47 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test checks that the fields of function returns and the escaping fields respect the annotations
+of the fields themselves in no-infer mode.
+
+<nilaway no inference>
+*/
+package noinfer
+
+// nilable(nilablePtr)
+type A struct {
+	ptr        *int
+	nilablePtr *int
+}
+
+func giveA() *A {
+	return &A{ptr: new(int)}
+}
+
+func giveNilA() *A {
+	t := &A{ptr: new(int)}
+	t.ptr = nil
+	return t //want "field `ptr` returned by result 0 of `giveNilA\\(\\)`" "field `ptr` escaped out of our analysis scope"
+}
+
+func readFields() {
+	a := giveA()
+	print(*a.ptr)
+	print(*a.nilablePtr) //want "field `nilablePtr` of result 0 of `giveA\\(\\)` dereferenced"
+}
+
+func escape() *A {
+	return &A{} //want "uninitialized field `ptr` returned by result 0" "uninitialized field `ptr` escaped"
+}
@@ -0,0 +1,52 @@
+//  Copyright (c) 2024 Uber Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+This test checks that the nilability annotations in the `nilaway:"..."` struct tags are read in
+addition to the ones in the doc comments.
+
+<nilaway no inference>
+*/
+package structtags
+
+// nonnil(overridden)
+type message struct {
+	plain      *int
+	nilable    *int   `json:"nilable,omitempty" nilaway:"nilable"`
+	slice      []*int `nilaway:"nonnil"`
+	deep       []*int `nilaway:"nonnil,deepnilable"`
+	both       []*int `nilaway:"nilable,deepnilable"`
+	overridden *int   `nilaway:"nilable"`
+	a, b       *int   `nilaway:"nilable"`
+}
+
+func readFields(m *message) {
+	print(*m.plain)
+	print(*m.nilable) //want "dereferenced"
+	print(m.slice[0])
+	print(*m.slice[0])
+	print(*m.deep[0]) //want "dereferenced"
+	print(m.both[0])  //want "sliced into"
+	print(*m.overridden)
+	print(*m.a) //want "dereferenced"
+	print(*m.b) //want "dereferenced"
+}
+
+func writeFields(m *message) {
+	m.plain = nil //want "assigned into field `plain`"
+	m.nilable = nil
+	m.slice = nil //want "assigned into field `slice`"
+	m.deep[0] = nil
+	m.overridden = nil //want "assigned into field `overridden`"
+}
@@ -0,0 +1,64 @@
+// Package ignoredirective is meant to check if the `//nilaway:ignore` directives suppress the
+// diagnostics, and if our check-ignore-directives flag reports the misused directives.
+package ignoredirective
+
+var dummy bool
+
+func sameLine() {
+	var x *int
+	print(*x) //nilaway:ignore x is never dereferenced in production
+}
+
+func statement() {
+	var x *int
+	//nilaway:ignore the whole statement is guarded by the caller
+	if dummy {
+		print(*x)
+	}
+	print(*x) //want "dereferenced"
+}
+
+// function has all diagnostics suppressed.
+//
+//nilaway:ignore this function is only called in tests
+func function() {
+	var x *int
+	print(*x)
+	print(*x)
+}
+
+func noReason() {
+	var x *int
+	print(*x) //nilaway:ignore // want "directive must be followed by a reason" "dereferenced"
+}
+
+func unused() {
+	x := new(int)
+	print(*x) //nilaway:ignore x is never nil // want "unused `//nilaway:ignore` directive"
+}
+
+func grouping() {
+	var x *int
+	print(*x) //nilaway:ignore the suppressed diagnostic should not hide the others in the group
+	print(*x) //want "dereferenced"
+}
+
+func multiLineCall() {
+	var x *int
+	print( //nilaway:ignore the statement starting on this line is suppressed
+		*x,
+		*x,
+	)
+	print(
+		*x, //want "dereferenced"
+		0,
+	)
+}
+
+func multiLineIf() {
+	var x *int
+	if dummy && //nilaway:ignore only the header of the statement is suppressed
+		*x == 0 {
+		print(*x) //want "dereferenced"
+	}
+}
@@ -0,0 +1,13 @@
+// Package stdlibmodeldisabled tests that the sites in the (unanalyzed) standard library packages
+// fall back to the defaults if the bundled standard library model is disabled.
+package stdlibmodeldisabled
+
+import (
+	"net/url"
+	"text/template"
+)
+
+func test(u *url.URL, tmpl *template.Template) {
+	print(*u.User)
+	print(tmpl.Lookup("foo").Tree)
+}
@@ -0,0 +1,44 @@
+// Package stubs tests the annotations declared in the external stub files (see stubs.yaml), which
+// override the inference for the sites in the upstream packages (including the standard library
+// packages) even if they are not analyzed, while the annotations in the source code take precedence
+// over the stubs.
+package stubs
+
+import (
+	"flag"
+
+	"stubs/upstream"
+)
+
+func test() {
+	print(*upstream.Find("key")) //want "dereferenced"
+
+	cfg := upstream.Default
+	print(cfg.Timeout) //want "accessed field"
+	if cfg != nil {
+		print(*cfg.Timeout) //want "dereferenced"
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	print(fs.Lookup("flag").Name) //want "accessed field"
+	if f := fs.Lookup("flag"); f != nil {
+		print(f.Name)
+	}
+}
+
+// nonnil(result 0)
+func annotated() *int {
+	v := 1
+	return &v
+}
+
+func unannotated() *int {
+	v := 1
+	return &v
+}
+
+func local() {
+	// The in-source annotations take precedence over the conflicting stubs.
+	print(*annotated())
+	print(*unannotated()) //want "dereferenced"
+}
@@ -0,0 +1,21 @@
+// Package upstream is an upstream package excluded from analysis, where the nilability of its
+// sites is declared in the stub file instead.
+package upstream
+
+// Find returns nil if the key is not found, which NilAway does not know since this package is not
+// analyzed.
+func Find(key string) *int {
+	if key == "" {
+		return nil
+	}
+	v := 1
+	return &v
+}
+
+// Config is a config.
+type Config struct {
+	Timeout *int
+}
+
+// Default is the default config, which is nil until it is loaded.
+var Default *Config
@@ -0,0 +1,34 @@
+// Package suggestedfix is meant to check the suggested fixes that insert nil guards.
+package suggestedfix
+
+type S struct {
+	f int
+}
+
+func noResults() {
+	var x *int
+	print(*x) //want "dereferenced"
+}
+
+func errorResult() (int, string, *S, S, error) {
+	var s *S
+	print(s.f) //want "accessed field `f`"
+	return 0, "", nil, S{}, nil
+}
+
+func nonErrorResult() int {
+	var m map[int]int
+	m[0] = 1 //want "written to at an index"
+	return 0
+}
+
+func declaringStatement() int {
+	var x *int
+	y := *x //want "dereferenced"
+	return y
+}
+
+func returningStatement() int {
+	var x *int
+	return *x //want "dereferenced"
+}
@@ -281,6 +281,50 @@ func TypeBarsNilness(t types.Type) bool {
 	}
 }
 
+// CoreType returns the underlying type of `t`, except that for a type parameter it returns the
+// single underlying type shared by all types in its type set (e.g., `[]E` for `S ~[]E`), and nil
+// if there is no such type (e.g., for the `any` constraint).
+func CoreType(t types.Type) types.Type {
+	tp, ok := t.(*types.TypeParam)
+	if !ok {
+		return t.Underlying()
+	}
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	return interfaceCoreType(iface)
+}
+
+// interfaceCoreType returns the single underlying type of the type terms in the constraint
+// interface (including the embedded ones), and nil if there is none or there are multiple.
+func interfaceCoreType(iface *types.Interface) types.Type {
+	var core types.Type
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		var terms []types.Type
+		switch e := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := 0; j < e.Len(); j++ {
+				terms = append(terms, e.Term(j).Type().Underlying())
+			}
+		default:
+			if embedded, ok := e.Underlying().(*types.Interface); !ok {
+				terms = append(terms, e.Underlying())
+			} else if t := interfaceCoreType(embedded); t != nil {
+				terms = append(terms, t)
+			}
+		}
+		for _, term := range terms {
+			if core == nil {
+				core = term
+			} else if !types.Identical(core, term) {
+				return nil
+			}
+		}
+	}
+	return core
+}
+
 // ExprBarsNilness returns if the expression can never be nil for the simple reason that nil does
 // not inhabit its type.
 func ExprBarsNilness(pass *analysis.Pass, expr ast.Expr) bool {